@@ -7,13 +7,16 @@ package configurationFile
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
-	"os/user"
 
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"sync"
+	"unicode"
 
 	"github.com/BurntSushi/toml"
 	"github.com/k0pernicus/goyave/consts"
@@ -29,17 +32,9 @@ func GetConfigurationFileContent(filePointer *os.File, bytesArray *[]byte) {
 	fileState, err := filePointer.Stat()
 	// If the file is empty, get the default structure and save it
 	if err != nil || fileState.Size() == 0 {
-		traces.WarningTracer.Println("No (or empty) configuration file - creating default one...")
+		traces.WarningTracer.Println("No (or empty) configuration file - creating default one (run 'goyave init' to customize it)...")
 		var fileBuffer bytes.Buffer
-		cLocalhost := utils.GetHostname()
-		cUser, err := user.Current()
-		var cUserName string
-		if err != nil {
-			cUserName = consts.DefaultUserName
-		} else {
-			cUserName = cUser.Username
-		}
-		defaultStructure := Default(cUserName, cLocalhost)
+		defaultStructure := Default(utils.GetUserName(), utils.GetHostname())
 		defaultStructure.Encode(&fileBuffer)
 		*bytesArray = fileBuffer.Bytes()
 	} else {
@@ -94,51 +89,350 @@ func Default(author string, hostname string) *ConfigurationFile {
 	}
 }
 
-/*AddRepository append the given repository to the list of local repositories, if it does not exists
+/*AddRepository append the given repository to the list of local repositories, if it does not exists.
+ *The target (VISIBLE or HIDDEN) is only used if the repository is new for the current host: an existing
+ *repository keeps his visibility state, even if his path changed.
  */
 func (c *ConfigurationFile) AddRepository(path, target string) error {
 	name := filepath.Base(path)
 	hostname := utils.GetHostname()
 	c.locker.Lock()
 	defer c.locker.Unlock()
-	robj, ok := c.Repositories[name]
-	// If the repository exists and the path is ok, stop
-	if ok && robj.Paths[hostname].Path == path {
-		return nil
+	// If the path is already known (the repository may have been renamed), stop
+	for _, repository := range c.Repositories {
+		if gpath, ok := repository.Paths[hostname]; ok && gpath.Path == path {
+			return nil
+		}
 	}
+	// Aliases are not resolved: an unrelated directory with the same name as an alias must not replace the path
+	// of the aliased repository
+	robj, ok := c.Repositories[name]
 	// Initialize the new GroupPath structure
 	cgroup := GroupPath{
-		Name: name,
-		Path: path,
+		Name:   name,
+		Path:   path,
+		Target: target,
 	}
 	// If the repository exists but the path is not ok, update it
 	if ok {
+		if robj.Paths == nil {
+			robj.Paths = make(map[string]GroupPath)
+			c.Repositories[name] = robj
+		}
+		if previous, known := robj.Paths[hostname]; known {
+			// The repository moved: keep the visibility chosen by the user
+			cgroup.Target = previous.Target
+			robj.Paths[hostname] = cgroup
+			return nil
+		}
 		robj.Paths[hostname] = cgroup
-		return nil
-	}
-	// Otherwise, create a new GitRepository structure, and append it in the Repositories field
-	c.Repositories[name] = GitRepository{
-		Name: name,
-		Paths: map[string]GroupPath{
-			hostname: cgroup,
-		},
-		URL: gitManip.GetRemoteURL(path),
+	} else {
+		// Otherwise, create a new GitRepository structure, and append it in the Repositories field
+		c.Repositories[name] = GitRepository{
+			Name: name,
+			Paths: map[string]GroupPath{
+				hostname: cgroup,
+			},
+			URL: gitManip.GetRemoteURL(path),
+		}
 	}
 	// If the user wants to add automatically new repositories as repositories to "follow", change
 	// his flag as a "visible" repository
+	if group := c.localGroup(); target == consts.VisibleFlag && !c.Groups[group].Contains(name) {
+		c.Groups[group] = append(c.Groups[group], name)
+	}
+	return nil
+}
+
+/*SetTarget sets the visibility (VISIBLE or HIDDEN) of the given repository, for the current host.
+ *A visible repository is a member of the local group (see localGroup), and is available in VisibleRepositories.
+ */
+func (c *ConfigurationFile) SetTarget(name, target string) error {
+	if target != consts.VisibleFlag && target != consts.HiddenFlag {
+		return fmt.Errorf("unknown target %s", target)
+	}
+	hostname := utils.GetHostname()
+	c.locker.Lock()
+	defer c.locker.Unlock()
+	name, ok := c.resolve(name)
+	if !ok {
+		return fmt.Errorf("the repository %s does not exist", name)
+	}
+	robj := c.Repositories[name]
+	gpath, ok := robj.Paths[hostname]
+	if !ok {
+		return fmt.Errorf("the repository %s is not registered for the host %s", name, hostname)
+	}
+	gpath.Target = target
+	robj.Paths[hostname] = gpath
+	group := c.localGroup()
 	if target == consts.VisibleFlag {
-		c.Groups[hostname] = append(c.Groups[hostname], name)
+		if !c.Groups[group].Contains(name) {
+			c.Groups[group] = append(c.Groups[group], name)
+		}
+		c.VisibleRepositories[name] = gpath.Path
+	} else {
+		c.Groups[group] = c.Groups[group].remove(name)
+		delete(c.VisibleRepositories, name)
 	}
 	return nil
 }
 
+/*MatchRepositories returns the names of the repositories registered for the current host, that match the
+ *given pattern.
+ *The pattern can be a repository name or alias, or a shell pattern (like "go*").
+ */
+func (c *ConfigurationFile) MatchRepositories(pattern string) ([]string, error) {
+	hostname := utils.GetHostname()
+	c.locker.RLock()
+	defer c.locker.RUnlock()
+	var names []string
+	for name, repository := range c.Repositories {
+		if _, ok := repository.Paths[hostname]; !ok {
+			continue
+		}
+		for _, candidate := range append([]string{name}, repository.Aliases...) {
+			matched, err := filepath.Match(pattern, candidate)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+/*RemoveRepository removes the given repository from the list of local repositories.
+ *By default, only the current host forgets the repository: his path and his group membership are removed,
+ *and the repository entry is deleted once no other host references it.
+ *If allHosts is true, the repository is removed from each group and deleted from the repositories list.
+ */
+func (c *ConfigurationFile) RemoveRepository(name string, allHosts bool) error {
+	hostname := utils.GetHostname()
+	c.locker.Lock()
+	defer c.locker.Unlock()
+	name, ok := c.resolve(name)
+	if !ok {
+		return fmt.Errorf("the repository %s does not exist", name)
+	}
+	robj := c.Repositories[name]
+	if allHosts {
+		for group := range c.Groups {
+			c.Groups[group] = c.Groups[group].remove(name)
+		}
+		delete(c.Repositories, name)
+		delete(c.VisibleRepositories, name)
+		return nil
+	}
+	group := c.localGroup()
+	_, hasPath := robj.Paths[hostname]
+	if !hasPath && !c.Groups[group].Contains(name) {
+		return fmt.Errorf("the repository %s is not registered for the host %s", name, hostname)
+	}
+	delete(robj.Paths, hostname)
+	c.Groups[group] = c.Groups[group].remove(name)
+	delete(c.VisibleRepositories, name)
+	// If no other host knows this repository, forget it
+	if len(robj.Paths) == 0 {
+		delete(c.Repositories, name)
+	}
+	return nil
+}
+
+/*GetRepositoryName returns the name of the repository stored at the given path, for the current host
+ */
+func (c *ConfigurationFile) GetRepositoryName(path string) (string, bool) {
+	hostname := utils.GetHostname()
+	path = filepath.Clean(path)
+	c.locker.RLock()
+	defer c.locker.RUnlock()
+	for name, repository := range c.Repositories {
+		if gpath, ok := repository.Paths[hostname]; ok && filepath.Clean(gpath.Path) == path {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+/*Entries returns each repository registered for each host, sorted by repository name and hostname.
+ *The Missing field is only computed for the current host, as other paths do not refer to the local hard drive.
+ */
+func (c *ConfigurationFile) Entries() []RepositoryEntry {
+	hostname := utils.GetHostname()
+	c.locker.RLock()
+	defer c.locker.RUnlock()
+	var entries []RepositoryEntry
+	for name, repository := range c.Repositories {
+		for host, gpath := range repository.Paths {
+			target := gpath.Target
+			if target == "" {
+				target = consts.HiddenFlag
+				if c.Groups[c.hostGroup(host, hostname)].Contains(name) {
+					target = consts.VisibleFlag
+				}
+			}
+			entry := RepositoryEntry{
+				Name:    name,
+				Aliases: append([]string{}, repository.Aliases...),
+				Tags:    append([]string{}, repository.Tags...),
+				Host:    host,
+				Path:    gpath.Path,
+				URL:     repository.URL,
+				Target:  target,
+				Groups:  []string{},
+			}
+			for group, members := range c.Groups {
+				if members.Contains(name) {
+					entry.Groups = append(entry.Groups, group)
+				}
+			}
+			sort.Strings(entry.Groups)
+			if host == hostname {
+				if _, err := os.Stat(gpath.Path); err != nil {
+					entry.Missing = true
+				}
+			}
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Name != entries[j].Name {
+			return entries[i].Name < entries[j].Name
+		}
+		return entries[i].Host < entries[j].Host
+	})
+	return entries
+}
+
 /*GetPath returns the local path file, for a given repository
  */
 func (c *ConfigurationFile) GetPath(repository string) (string, bool) {
-	gobj, ok := c.VisibleRepositories[repository]
+	name, ok := c.Resolve(repository)
+	if !ok {
+		return "", false
+	}
+	gobj, ok := c.VisibleRepositories[name]
 	return gobj, ok
 }
 
+/*Resolve returns the name of the repository identified by the given name or alias
+ */
+func (c *ConfigurationFile) Resolve(name string) (string, bool) {
+	c.locker.RLock()
+	defer c.locker.RUnlock()
+	return c.resolve(name)
+}
+
+/*resolve returns the name of the repository identified by the given name or alias.
+ *If the repository does not exist, it returns the given name.
+ *The caller must hold the locker.
+ */
+func (c *ConfigurationFile) resolve(name string) (string, bool) {
+	if _, ok := c.Repositories[name]; ok {
+		return name, true
+	}
+	for repositoryName, repository := range c.Repositories {
+		if Group(repository.Aliases).Contains(name) {
+			return repositoryName, true
+		}
+	}
+	return name, false
+}
+
+/*RenameRepository renames the given repository (identified by his name or an alias) for every host.
+ *The group memberships and the paths are updated too.
+ *If keepAlias is true, the old name becomes an alias of the repository.
+ */
+func (c *ConfigurationFile) RenameRepository(oldName, newName string, keepAlias bool) error {
+	c.locker.Lock()
+	defer c.locker.Unlock()
+	oldName, ok := c.resolve(oldName)
+	if !ok {
+		return fmt.Errorf("the repository %s does not exist", oldName)
+	}
+	if strings.TrimSpace(newName) == "" {
+		return fmt.Errorf("the new name of the repository %s can't be empty", oldName)
+	}
+	if oldName == newName {
+		return nil
+	}
+	if canonicalName, used := c.resolve(newName); used && canonicalName != oldName {
+		return fmt.Errorf("the name %s is already used by the repository %s", newName, canonicalName)
+	}
+	robj := c.Repositories[oldName]
+	robj.Name = newName
+	for host, gpath := range robj.Paths {
+		gpath.Name = newName
+		robj.Paths[host] = gpath
+	}
+	robj.Aliases = Group(robj.Aliases).remove(newName)
+	if keepAlias {
+		robj.Aliases = append(robj.Aliases, oldName)
+	}
+	delete(c.Repositories, oldName)
+	c.Repositories[newName] = robj
+	for group, members := range c.Groups {
+		for i, member := range members {
+			if member == oldName {
+				members[i] = newName
+			}
+		}
+		c.Groups[group] = members
+	}
+	if repositoryPath, ok := c.VisibleRepositories[oldName]; ok {
+		delete(c.VisibleRepositories, oldName)
+		c.VisibleRepositories[newName] = repositoryPath
+	}
+	return nil
+}
+
+/*AddAlias adds an alias to the given repository.
+ *An alias can't be the name (or the alias) of another repository.
+ */
+func (c *ConfigurationFile) AddAlias(name, alias string) error {
+	c.locker.Lock()
+	defer c.locker.Unlock()
+	name, ok := c.resolve(name)
+	if !ok {
+		return fmt.Errorf("the repository %s does not exist", name)
+	}
+	if strings.TrimSpace(alias) == "" {
+		return fmt.Errorf("an alias of the repository %s can't be empty", name)
+	}
+	if canonicalName, used := c.resolve(alias); used {
+		if canonicalName == name {
+			return nil
+		}
+		return fmt.Errorf("the name %s is already used by the repository %s", alias, canonicalName)
+	}
+	robj := c.Repositories[name]
+	robj.Aliases = append(robj.Aliases, alias)
+	c.Repositories[name] = robj
+	return nil
+}
+
+/*RemoveAlias removes an alias of the given repository
+ */
+func (c *ConfigurationFile) RemoveAlias(name, alias string) error {
+	c.locker.Lock()
+	defer c.locker.Unlock()
+	name, ok := c.resolve(name)
+	if !ok {
+		return fmt.Errorf("the repository %s does not exist", name)
+	}
+	robj := c.Repositories[name]
+	if !Group(robj.Aliases).Contains(alias) {
+		return fmt.Errorf("%s is not an alias of the repository %s", alias, name)
+	}
+	robj.Aliases = Group(robj.Aliases).remove(alias)
+	c.Repositories[name] = robj
+	return nil
+}
+
 /*Process initializes useful fields in the data structure
  */
 func (c *ConfigurationFile) Process() {
@@ -146,19 +440,64 @@ func (c *ConfigurationFile) Process() {
 	if c.Repositories == nil {
 		c.Repositories = make(map[string]GitRepository)
 	}
+	if c.Groups == nil {
+		c.Groups = make(map[string]Group)
+	}
 	// Otherwise, initialize useful fields
 	hostname := utils.GetHostname()
-	vrepositories, ok := c.Groups[hostname]
-	if !ok {
-		traces.InfoTracer.Printf("creating new group '%s'\n", hostname)
-		c.Groups[hostname] = []string{}
+	group := c.localGroup()
+	if _, ok := c.Groups[group]; !ok {
+		traces.InfoTracer.Printf("creating new group '%s'\n", group)
+		c.Groups[group] = []string{}
+	}
+	// Old configuration files do not store the visibility state: a repository is visible if it is a member
+	// of the local group, and hidden otherwise
+	for name, repository := range c.Repositories {
+		gpath, ok := repository.Paths[hostname]
+		if !ok {
+			continue
+		}
+		if gpath.Target == "" {
+			if c.Groups[group].Contains(name) {
+				gpath.Target = consts.VisibleFlag
+			} else {
+				gpath.Target = consts.HiddenFlag
+			}
+			repository.Paths[hostname] = gpath
+		}
+		// Keep the local group consistent with the visibility state
+		if gpath.Target == consts.HiddenFlag {
+			c.Groups[group] = c.Groups[group].remove(name)
+		} else if !c.Groups[group].Contains(name) {
+			c.Groups[group] = append(c.Groups[group], name)
+		}
 	}
 	c.VisibleRepositories = make(VisibleRepositories)
-	for _, repository := range vrepositories {
+	for _, repository := range c.Groups[group] {
 		c.VisibleRepositories[repository] = c.Repositories[repository].Paths[hostname].Path
 	}
 }
 
+/*localGroup returns the group of the visible repositories of the current host: the group chosen during the
+ *initialization (Local.Group), or the hostname for the configuration files that do not define one
+ */
+func (c *ConfigurationFile) localGroup() string {
+	if c.Local.Group != "" {
+		return c.Local.Group
+	}
+	return utils.GetHostname()
+}
+
+/*hostGroup returns the group of the visible repositories of the given host, hostname being the current host: the
+ *local group for the current host, and the group named after the host for the other ones
+ */
+func (c *ConfigurationFile) hostGroup(host, hostname string) string {
+	if host == hostname {
+		return c.localGroup()
+	}
+	return host
+}
+
 /*VisibleRepositories is a map structure to store, for each repository name (and the hostname), the associated path
  */
 type VisibleRepositories map[string]string
@@ -179,15 +518,55 @@ func (v VisibleRepositories) exists(name, path string) bool {
  *Properties:
  *	Name:
  * 		The custom name of the repository
+ *	Aliases:
+ *		Other names of the repository
+ *	Tags:
+ *		Free-form tags of the repository (like "work" or "oss")
  *  Paths:
  *		Path per group name
  *	URL:
  *		The remote URL of the repository (from origin)
  */
 type GitRepository struct {
-	Name  string               `toml:"name"`
-	Paths map[string]GroupPath `toml:"paths"`
-	URL   string               `toml:"url"`
+	Name    string               `toml:"name"`
+	Aliases []string             `toml:"aliases"`
+	Tags    []string             `toml:"tags"`
+	Paths   map[string]GroupPath `toml:"paths"`
+	URL     string               `toml:"url"`
+}
+
+/*RepositoryEntry represents a git repository registered for a given host
+ *
+ *Properties:
+ *	Name:
+ *		The name of the repository
+ *	Aliases:
+ *		Other names of the repository
+ *	Tags:
+ *		The tags of the repository
+ *	Host:
+ *		The hostname
+ *	Path:
+ *		The path of the repository, for this host
+ *	URL:
+ *		The remote URL of the repository (from origin)
+ *	Target:
+ *		The visibility of the repository for this host (VISIBLE or HIDDEN)
+ *	Groups:
+ *		The groups that contain the repository
+ *	Missing:
+ *		Is the path no longer existing in the hard drive? (only for the current host)
+ */
+type RepositoryEntry struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+	Tags    []string `json:"tags"`
+	Host    string   `json:"host"`
+	Path    string   `json:"path"`
+	URL     string   `json:"url"`
+	Target  string   `json:"target"`
+	Groups  []string `json:"groups"`
+	Missing bool     `json:"missing"`
 }
 
 /*GroupPath represents the structure of a local path, using a given group
@@ -197,16 +576,37 @@ type GitRepository struct {
  *		The name of the local git repository
  *	Path:
  *		A string that points to the local git repository
+ *	Target:
+ *		The visibility of the repository for this group (VISIBLE or HIDDEN)
  */
 type GroupPath struct {
-	Name string
-	Path string
+	Name   string
+	Path   string
+	Target string
 }
 
 /*Group represents a group of git repositories names
  */
 type Group []string
 
+/*Contains returns if the given repository name is a member of the group
+ */
+func (g Group) Contains(name string) bool {
+	return utils.SliceIndex(len(g), func(i int) bool { return g[i] == name }) != -1
+}
+
+/*remove returns the group without the given repository name
+ */
+func (g Group) remove(name string) Group {
+	group := Group{}
+	for _, member := range g {
+		if member != name {
+			group = append(group, member)
+		}
+	}
+	return group
+}
+
 /*LocalInformations represents your local configuration of Goyave
  *
  *Properties:
@@ -214,23 +614,75 @@ type Group []string
  *		The default entry to store a git repository (hidden or visible)
  *	Group:
  *		The current group name.
+ *	CrawlRoots:
+ *		The directories to crawl in order to find git repositories (the user home directory if empty)
  */
 type LocalInformations struct {
 	DefaultTarget string
 	Group         string
+	CrawlRoots    []string
+}
+
+/*AddTag adds a tag to the given repository.
+ *A tag can't be empty, or contain a comma (used to give many tags to the selectors) or a space.
+ */
+func (c *ConfigurationFile) AddTag(name, tag string) error {
+	c.locker.Lock()
+	defer c.locker.Unlock()
+	name, ok := c.resolve(name)
+	if !ok {
+		return fmt.Errorf("the repository %s does not exist", name)
+	}
+	if tag == "" || strings.Contains(tag, ",") || strings.IndexFunc(tag, unicode.IsSpace) != -1 {
+		return fmt.Errorf("the tag %q is not valid: a tag can't be empty, or contain a comma or a space", tag)
+	}
+	robj := c.Repositories[name]
+	if !Group(robj.Tags).Contains(tag) {
+		robj.Tags = append(robj.Tags, tag)
+		sort.Strings(robj.Tags)
+		c.Repositories[name] = robj
+	}
+	return nil
+}
+
+/*RemoveTag removes a tag of the given repository
+ */
+func (c *ConfigurationFile) RemoveTag(name, tag string) error {
+	c.locker.Lock()
+	defer c.locker.Unlock()
+	name, ok := c.resolve(name)
+	if !ok {
+		return fmt.Errorf("the repository %s does not exist", name)
+	}
+	robj := c.Repositories[name]
+	if !Group(robj.Tags).Contains(tag) {
+		return fmt.Errorf("the repository %s is not tagged %s", name, tag)
+	}
+	robj.Tags = Group(robj.Tags).remove(tag)
+	c.Repositories[name] = robj
+	return nil
+}
+
+/*GetTags returns the tags of the given repository
+ */
+func (c *ConfigurationFile) GetTags(name string) []string {
+	c.locker.RLock()
+	defer c.locker.RUnlock()
+	name, _ = c.resolve(name)
+	return c.Repositories[name].Tags
 }
 
 /*DecodeString is a function to decode an entire string (which is the content of a given TOML file) to a ConfigurationFile structure
  */
 func DecodeString(c *ConfigurationFile, data string) error {
-	_, err := toml.Decode(data, *c)
+	_, err := toml.Decode(data, c)
 	return err
 }
 
 /*DecodeBytesArray is a function to decode an entire string (which is the content of a given TOML file) to a ConfigurationFile structure
  */
 func DecodeBytesArray(c *ConfigurationFile, data []byte) error {
-	_, err := toml.Decode(string(data[:]), *c)
+	_, err := toml.Decode(string(data[:]), c)
 	return err
 }
 
@@ -86,3 +86,38 @@ func TestEncode(t *testing.T) {
 	Encode(&localStructure, buffer)
 	fmt.Println(buffer)
 }
+
+func TestAddTag(t *testing.T) {
+	configurationStructure := ConfigurationFile{
+		Repositories: map[string]GitRepository{
+			"goyave": GitRepository{Name: "goyave"},
+		},
+	}
+	tests := []struct {
+		tag   string
+		valid bool
+	}{
+		{"work", true},
+		{"open-source", true},
+		{"", false},
+		{"work,oss", false},
+		{",", false},
+		{"open source", false},
+		{"work\t", false},
+	}
+	for _, test := range tests {
+		err := configurationStructure.AddTag("goyave", test.tag)
+		if test.valid && err != nil {
+			t.Errorf("The tag %q should be accepted, got the error %s.", test.tag, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("The tag %q should be rejected.", test.tag)
+		}
+	}
+	if tags := configurationStructure.Repositories["goyave"].Tags; len(tags) != 2 {
+		t.Errorf("The number of tags is not correct, got %d instead of %d.", len(tags), 2)
+	}
+	if err := configurationStructure.AddTag("unknown", "work"); err == nil {
+		t.Error("A tag can't be added to an unknown repository.")
+	}
+}
@@ -0,0 +1,173 @@
+package configurationFile
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/k0pernicus/goyave/gitManip"
+	"github.com/k0pernicus/goyave/utils"
+)
+
+/*Problem represents an inconsistency found in the configuration file
+ *
+ *Properties:
+ *	Description:
+ *		A readable description of the problem
+ *	fix:
+ *		The function to call to fix the problem, if this one can be safely fixed (the caller must hold the locker)
+ */
+type Problem struct {
+	Description string
+	fix         func(c *ConfigurationFile)
+}
+
+/*Fixable returns if the problem can be safely fixed
+ */
+func (p Problem) Fixable() bool {
+	return p.fix != nil
+}
+
+/*sortedKeys returns the names of the repositories, sorted
+ */
+func (c *ConfigurationFile) sortedKeys() []string {
+	var names []string
+	for name := range c.Repositories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*Diagnose checks the consistency of the configuration file, and returns the problems found
+ */
+func (c *ConfigurationFile) Diagnose() []Problem {
+	hostname := utils.GetHostname()
+	c.locker.RLock()
+	defer c.locker.RUnlock()
+	var problems []Problem
+	var groups []string
+	for group := range c.Groups {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	hosts := map[string]bool{hostname: true}
+	for _, robj := range c.Repositories {
+		for host := range robj.Paths {
+			hosts[host] = true
+		}
+	}
+	// Group members must be known repositories, with a path for the group host
+	for _, group := range groups {
+		seen := make(map[string]bool)
+		// The local group contains the visible repositories of the current host
+		groupHost := group
+		if group == c.localGroup() {
+			groupHost = hostname
+		}
+		for _, member := range c.Groups[group] {
+			group, member := group, member
+			robj, ok := c.Repositories[member]
+			switch {
+			case !ok:
+				problems = append(problems, Problem{
+					Description: fmt.Sprintf("the group %s contains the unknown repository %s", group, member),
+					fix:         func(c *ConfigurationFile) { c.Groups[group] = c.Groups[group].remove(member) },
+				})
+			case seen[member]:
+				problems = append(problems, Problem{
+					Description: fmt.Sprintf("the group %s contains the repository %s many times", group, member),
+					fix: func(c *ConfigurationFile) {
+						c.Groups[group] = append(c.Groups[group].remove(member), member)
+					},
+				})
+			case hosts[groupHost] && robj.Paths[groupHost].Path == "":
+				problems = append(problems, Problem{
+					Description: fmt.Sprintf("the group %s contains the repository %s, which has no path for this host", group, member),
+					fix:         func(c *ConfigurationFile) { c.Groups[group] = c.Groups[group].remove(member) },
+				})
+			}
+			seen[member] = true
+		}
+	}
+	paths := make(map[string]map[string]string)
+	for _, name := range c.sortedKeys() {
+		name, robj := name, c.Repositories[name]
+		var hosts []string
+		for host := range robj.Paths {
+			hosts = append(hosts, host)
+		}
+		sort.Strings(hosts)
+		for _, host := range hosts {
+			host, gpath := host, robj.Paths[host]
+			// Empty paths can't be used to load or check the repository
+			if gpath.Path == "" {
+				problems = append(problems, Problem{
+					Description: fmt.Sprintf("the repository %s has an empty path for the host %s", name, host),
+					fix: func(c *ConfigurationFile) {
+						delete(c.Repositories[name].Paths, host)
+						group := c.hostGroup(host, hostname)
+						c.Groups[group] = c.Groups[group].remove(name)
+						delete(c.VisibleRepositories, name)
+					},
+				})
+				continue
+			}
+			// Two repositories can't share the same path
+			if paths[host] == nil {
+				paths[host] = make(map[string]string)
+			}
+			if other, ok := paths[host][gpath.Path]; ok {
+				problems = append(problems, Problem{
+					Description: fmt.Sprintf("the repositories %s and %s have the same path %s for the host %s", other, name, gpath.Path, host),
+				})
+			}
+			paths[host][gpath.Path] = name
+		}
+		// Repositories without URL can't be loaded on another host
+		if robj.URL == "" {
+			problem := Problem{Description: fmt.Sprintf("the repository %s has no remote URL", name)}
+			if gpath, ok := robj.Paths[hostname]; ok && gpath.Path != "" {
+				if url := gitManip.GetRemoteURL(gpath.Path); url != "" {
+					problem.Description += fmt.Sprintf(" (found %s)", url)
+					problem.fix = func(c *ConfigurationFile) {
+						robj := c.Repositories[name]
+						robj.URL = url
+						c.Repositories[name] = robj
+					}
+				}
+			}
+			problems = append(problems, problem)
+		}
+		// Aliases must not hide other repositories
+		for _, alias := range robj.Aliases {
+			if _, ok := c.Repositories[alias]; ok {
+				alias := alias
+				problems = append(problems, Problem{
+					Description: fmt.Sprintf("the alias %s of the repository %s is the name of another repository", alias, name),
+					fix: func(c *ConfigurationFile) {
+						robj := c.Repositories[name]
+						robj.Aliases = Group(robj.Aliases).remove(alias)
+						c.Repositories[name] = robj
+					},
+				})
+			}
+		}
+	}
+	return problems
+}
+
+/*Fix fixes the given problems, if they can be safely fixed.
+ *This method returns the number of fixed problems.
+ */
+func (c *ConfigurationFile) Fix(problems []Problem) int {
+	c.locker.Lock()
+	defer c.locker.Unlock()
+	fixed := 0
+	for _, problem := range problems {
+		if problem.Fixable() {
+			problem.fix(c)
+			fixed++
+		}
+	}
+	return fixed
+}
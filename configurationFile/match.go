@@ -0,0 +1,130 @@
+package configurationFile
+
+import (
+	"sort"
+	"strings"
+)
+
+/*MatchKind represents how a repository matches a pattern, from the best to the worst match
+ */
+type MatchKind int
+
+const (
+	// MatchExact means the pattern is the name (or an alias) of the repository
+	MatchExact MatchKind = iota
+	// MatchPrefix means the name (or an alias) of the repository starts with the pattern
+	MatchPrefix
+	// MatchSubstring means the name (or an alias) of the repository contains the pattern
+	MatchSubstring
+	// MatchFuzzy means the name (or an alias) of the repository contains each character of the pattern, in order
+	MatchFuzzy
+	// noMatch means the repository does not match the pattern
+	noMatch
+)
+
+/*Map to match the MatchKind enum type with a string
+ */
+var matchKindToString = map[MatchKind]string{
+	MatchExact:     "exact",
+	MatchPrefix:    "prefix",
+	MatchSubstring: "substring",
+	MatchFuzzy:     "fuzzy",
+}
+
+/*String returns a readable description of the match kind
+ */
+func (k MatchKind) String() string {
+	return matchKindToString[k]
+}
+
+/*PathMatch represents a visible repository that matches a pattern
+ *
+ *The structure is:
+ *	Name:
+ *		The name of the repository.
+ *	Path:
+ *		The path of the repository, for the current host.
+ *	Kind:
+ *		How the repository matches the pattern.
+ */
+type PathMatch struct {
+	Name string
+	Path string
+	Kind MatchKind
+}
+
+/*isSubsequence returns if each character of pattern is in s, in the same order
+ */
+func isSubsequence(pattern, s string) bool {
+	remaining := []rune(pattern)
+	for _, r := range s {
+		if len(remaining) == 0 {
+			break
+		}
+		if r == remaining[0] {
+			remaining = remaining[1:]
+		}
+	}
+	return len(remaining) == 0
+}
+
+/*matchKind returns how the candidate matches the pattern.
+ *The comparison is case insensitive, except for the exact match.
+ */
+func matchKind(pattern, candidate string) MatchKind {
+	if pattern == candidate {
+		return MatchExact
+	}
+	pattern, candidate = strings.ToLower(pattern), strings.ToLower(candidate)
+	switch {
+	case pattern == candidate:
+		return MatchExact
+	case strings.HasPrefix(candidate, pattern):
+		return MatchPrefix
+	case strings.Contains(candidate, pattern):
+		return MatchSubstring
+	case isSubsequence(pattern, candidate):
+		return MatchFuzzy
+	}
+	return noMatch
+}
+
+/*FindPaths returns the visible repositories whose name (or one of the aliases) matches the given pattern.
+ *The matches are sorted from the best to the worst one: by kind of match, then by score (the highest first, if
+ *the score function is not nil), then by name length and name.
+ */
+func (c *ConfigurationFile) FindPaths(pattern string, score func(path string) float64) []PathMatch {
+	c.locker.RLock()
+	defer c.locker.RUnlock()
+	var matches []PathMatch
+	for name, path := range c.VisibleRepositories {
+		best := matchKind(pattern, name)
+		for _, alias := range c.Repositories[name].Aliases {
+			if kind := matchKind(pattern, alias); kind < best {
+				best = kind
+			}
+		}
+		if best != noMatch {
+			matches = append(matches, PathMatch{Name: name, Path: path, Kind: best})
+		}
+	}
+	scores := make(map[string]float64)
+	if score != nil {
+		for _, match := range matches {
+			scores[match.Path] = score(match.Path)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Kind != matches[j].Kind {
+			return matches[i].Kind < matches[j].Kind
+		}
+		if scores[matches[i].Path] != scores[matches[j].Path] {
+			return scores[matches[i].Path] > scores[matches[j].Path]
+		}
+		if len(matches[i].Name) != len(matches[j].Name) {
+			return len(matches[i].Name) < len(matches[j].Name)
+		}
+		return matches[i].Name < matches[j].Name
+	})
+	return matches
+}
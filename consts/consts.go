@@ -15,5 +15,14 @@ const HiddenFlag = "HIDDEN"
 // ConfigurationFileName is the configuration file name of Goyave
 const ConfigurationFileName = ".goyave"
 
+// StateFileName is the file name of the local state of Goyave (the use of each repository)
+const StateFileName = ".goyave_state"
+
 // GitFileName is the name of the git directory, in a git repository
 const GitFileName = ".git"
+
+// LibGit2Major is the major version of libgit2 supported by Goyave
+const LibGit2Major = 0
+
+// LibGit2Minor is the minor version of libgit2 supported by Goyave
+const LibGit2Minor = 27
@@ -0,0 +1,105 @@
+package gitManip
+
+import (
+	"strings"
+	"time"
+
+	git "gopkg.in/libgit2/git2go.v27"
+)
+
+/*BranchInfo contains informations about a local branch
+ *
+ *The structure is:
+ *	Name:
+ *		The name of the branch.
+ *	IsHead:
+ *		Is the branch the current one?
+ *	Upstream:
+ *		The name of the upstream branch (empty if there is no upstream branch).
+ *	Ahead:
+ *		The number of commits ahead of the upstream branch.
+ *	Behind:
+ *		The number of commits behind the upstream branch.
+ *	LastCommit:
+ *		The date of the last commit of the branch.
+ *	Merged:
+ *		Is the branch merged into the default branch?
+ */
+type BranchInfo struct {
+	Name       string
+	IsHead     bool
+	Upstream   string
+	Ahead      int
+	Behind     int
+	LastCommit time.Time
+	Merged     bool
+}
+
+/*defaultBranch returns the target of the default branch of the repository: the HEAD of the origin remote, or the
+ *local master (or main) branch.
+ */
+func (g *GitObject) defaultBranch() (string, *git.Oid, error) {
+	if originHead, err := g.repository.References.Lookup("refs/remotes/origin/HEAD"); err == nil {
+		if resolved, err := originHead.Resolve(); err == nil {
+			return strings.TrimPrefix(resolved.Shorthand(), "origin/"), resolved.Target(), nil
+		}
+	}
+	var lastErr error
+	for _, name := range []string{"master", "main"} {
+		branch, err := g.repository.LookupBranch(name, git.BranchLocal)
+		if err == nil {
+			return name, branch.Target(), nil
+		}
+		lastErr = err
+	}
+	return "", nil, lastErr
+}
+
+/*Branches returns informations about each local branch of the current git repository.
+ *If the default branch can't be found, no branch is considered as merged.
+ *If there is an error processing the request, it returns this one.
+ */
+func (g *GitObject) Branches() ([]BranchInfo, error) {
+	if !g.isAccessible() {
+		return nil, g.accessible
+	}
+	defaultName, defaultTarget, defaultErr := g.defaultBranch()
+	iterator, err := g.repository.NewBranchIterator(git.BranchLocal)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Free()
+	var branches []BranchInfo
+	err = iterator.ForEach(func(branch *git.Branch, branchType git.BranchType) error {
+		name, err := branch.Name()
+		if err != nil {
+			return err
+		}
+		info := BranchInfo{Name: name}
+		if info.IsHead, err = branch.IsHead(); err != nil {
+			return err
+		}
+		commit, err := g.repository.LookupCommit(branch.Target())
+		if err != nil {
+			return err
+		}
+		info.LastCommit = commit.Committer().When
+		if upstream, err := branch.Upstream(); err == nil {
+			info.Upstream = upstream.Shorthand()
+			if info.Ahead, info.Behind, err = g.repository.AheadBehind(branch.Target(), upstream.Target()); err != nil {
+				return err
+			}
+		}
+		// The default branch is not considered as merged into itself
+		if defaultErr == nil && name != defaultName {
+			if branch.Target().Equal(defaultTarget) {
+				info.Merged = true
+			} else if info.Merged, err = g.repository.DescendantOf(defaultTarget, branch.Target()); err != nil {
+				return err
+			}
+		}
+		branches = append(branches, info)
+		return nil
+	})
+	return branches, err
+}
@@ -3,10 +3,6 @@ package gitManip
 import (
 	"fmt"
 
-	"github.com/fatih/color"
-
-	"bytes"
-
 	"github.com/k0pernicus/goyave/traces"
 	git "gopkg.in/libgit2/git2go.v27"
 )
@@ -30,7 +26,7 @@ var repositoryStateToString = map[git.RepositoryState]string{
  */
 var statusOption = git.StatusOptions{
 	Show:     git.StatusShowIndexAndWorkdir,
-	Flags:    git.StatusOptIncludeUntracked,
+	Flags:    git.StatusOptIncludeUntracked | git.StatusOptRenamesHeadToIndex,
 	Pathspec: []string{},
 }
 
@@ -47,7 +43,7 @@ var statusOption = git.StatusOptions{
 type GitObject struct {
 	accessible error
 	path       string
-	repository git.Repository
+	repository *git.Repository
 }
 
 /*New is a constructor for GitObject
@@ -58,7 +54,7 @@ type GitObject struct {
  */
 func New(path string) *GitObject {
 	r, err := git.OpenRepository(path)
-	return &GitObject{accessible: err, path: path, repository: *r}
+	return &GitObject{accessible: err, path: path, repository: r}
 }
 
 /*Clone is cloning a given repository, from a public URL
@@ -103,115 +99,47 @@ func (g *GitObject) isAccessible() bool {
 }
 
 /*Status prints the current status of the repository, accessible via the structure path field.
- *This method works only if the repository is accessible.
+ *If the status can't be read, the error is printed and returned.
  */
-func (g *GitObject) Status() {
-	if g.isAccessible() {
-		if err := g.printChanges(); err != nil {
-			color.RedString("Impossible to get stats from %s, due to error %s", g.path, err)
-		}
-	} else {
-		color.RedString("Repository %s not found!", g.path)
-	}
+func (g *GitObject) Status() error {
+	status := g.Inspect()
+	fmt.Print(status.Text())
+	return status.Err
 }
 
-/*getDiffWithWT returns the difference between the working tree and the index, for the current git repository.
- *If there is an error processing the request, it returns an error.
+/*IsDirty returns if the current git repository contains changes (staged, unstaged, untracked or conflicted
+ *files).
+ *If there is an error processing the request, it returns this one.
  */
-func (g *GitObject) getDiffWithWT() (*git.Diff, error) {
-	// Get the index of the repository
-	currentIndex, err := g.repository.Index()
-	if err != nil {
-		return nil, err
+func (g *GitObject) IsDirty() (bool, error) {
+	if !g.isAccessible() {
+		return false, g.accessible
 	}
-	// Get the default diff options, and add it custom flags
-	defaultDiffOptions, err := git.DefaultDiffOptions()
-	if err != nil {
-		return nil, err
-	}
-	defaultDiffOptions.Flags = defaultDiffOptions.Flags | git.DiffNormal | git.DiffIncludeUntracked | git.DiffIncludeTypeChange
-	// Check the difference between the working directory and the index
-	diff, err := g.repository.DiffIndexToWorkdir(currentIndex, &defaultDiffOptions)
-	if err != nil {
-		return nil, err
-	}
-	return diff, nil
+	changes, err := g.changes()
+	return len(changes) > 0, err
 }
 
-func (g *GitObject) getCommitsAheadBehind() (int, int, error) {
-	repositoryHead, err := g.repository.Head()
-	// Check upstream branch head
-	cBranch := repositoryHead.Branch()
-	cReference, err := cBranch.Upstream()
-	if err != nil {
-		return -1, -1, err
-	}
-	cReferenceTarget := cReference.Target()
-	cRepositoryTarget := repositoryHead.Target()
-	commitsAhead, commitsBehind, err := g.repository.AheadBehind(cRepositoryTarget, cReferenceTarget)
-	return commitsAhead, commitsBehind, err
+/*LibGit2Version returns the version of the libgit2 library used by goyave
+ */
+func LibGit2Version() (int, int, int) {
+	return git.Version()
 }
 
-/*printChanges prints out all changes for the current git repository.
- *If there is an error processing the request, it returns this one.
+/*Check returns an error if the git repository, pointed by the given path, can't be opened or read.
  */
-func (g *GitObject) printChanges() error {
-	diff, err := g.getDiffWithWT()
-	var buffer bytes.Buffer
+func Check(path string) error {
+	r, err := git.OpenRepository(path)
 	if err != nil {
 		return err
 	}
-	
-	numDeltas, err := diff.NumDeltas()
-	if err != nil {
+	defer r.Free()
+	// A new repository does not have any HEAD yet
+	if headUnborn, err := r.IsHeadUnborn(); err != nil || headUnborn {
 		return err
 	}
-	
-	headDetached, err := g.repository.IsHeadDetached()
-	if err != nil {
+	if _, err := r.Head(); err != nil {
 		return err
 	}
-	if headDetached {
-		outputHead := fmt.Sprintf("%s", color.RedString("\t/!\\ The repository's HEAD is detached! /!\\\n"))
-		buffer.WriteString(outputHead)
-	}
-	
-	if numDeltas > 0 {
-		buffer.WriteString(fmt.Sprintf("%s %9s\t[%d modification(s)]\n", color.RedString("✘"), g.path, numDeltas))
-		for i := 0; i < numDeltas; i++ {
-			delta, _ := diff.GetDelta(i)
-			currentStatus := delta.Status
-			newFile := delta.NewFile.Path
-			oldFile := delta.OldFile.Path
-			switch currentStatus {
-			case git.DeltaAdded:
-				buffer.WriteString(fmt.Sprintf("\t===> %s has been added!\n", color.MagentaString(newFile)))
-			case git.DeltaDeleted:
-				buffer.WriteString(fmt.Sprintf("\t===> %s has been deleted!\n", color.MagentaString(newFile)))
-			case git.DeltaModified:
-				buffer.WriteString(fmt.Sprintf("\t===> %s has been modified!\n", color.MagentaString(newFile)))
-			case git.DeltaRenamed:
-				buffer.WriteString(fmt.Sprintf("\t===> %s has been renamed to %s!\n", color.MagentaString(oldFile), color.MagentaString(newFile)))
-			case git.DeltaUntracked:
-				buffer.WriteString(fmt.Sprintf("\t===> %s is untracked - please to add it or update the gitignore file!\n", color.MagentaString(newFile)))
-			case git.DeltaTypeChange:
-				buffer.WriteString(fmt.Sprintf("\t===> the type of %s has been changed from %d to %d!", color.MagentaString(newFile), delta.OldFile.Mode, delta.NewFile.Mode))
-			}
-		}
-	} else {
-		buffer.WriteString(fmt.Sprintf("%s %s\n", color.GreenString("✔"), g.path))
-	}
-	
-	commitsAhead, commitsBehind, err := g.getCommitsAheadBehind()
-	if err == nil {
-		if commitsAhead != 0 {
-			buffer.WriteString(fmt.Sprintf("\t%s %d commits AHEAD - Soon, you will need to push your modifications\n", color.RedString("⟳"), commitsAhead))
-		}
-		if commitsBehind != 0 {
-			buffer.WriteString(fmt.Sprintf("\t%s %d commits BEHIND - Soon, you will need to pull the modifications from the remote branch\n", color.RedString("⟲"), commitsBehind))
-		}
-	}
-	
-	fmt.Print(buffer.String())
-	return nil
+	_, err = r.Index()
+	return err
 }
@@ -0,0 +1,161 @@
+package gitManip
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	git "gopkg.in/libgit2/git2go.v27"
+)
+
+/*binaryCheckSize is the number of bytes to read, to know if a file is a binary one (like git does)
+ */
+const binaryCheckSize = 8000
+
+/*GrepMatch represents a line that matches a pattern, in a file of a git repository
+ *
+ *The structure is:
+ *	Path:
+ *		The path of the file, relative to the repository.
+ *	Line:
+ *		The line number (starting at 1).
+ *	Text:
+ *		The content of the line.
+ */
+type GrepMatch struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+/*grepContent appends to matches each line of the content that matches the pattern.
+ *Binary contents are ignored.
+ */
+func grepContent(pattern *regexp.Regexp, path string, content []byte, matches []GrepMatch) []GrepMatch {
+	header := content
+	if len(header) > binaryCheckSize {
+		header = header[:binaryCheckSize]
+	}
+	if len(content) == 0 || bytes.IndexByte(header, 0) != -1 {
+		return matches
+	}
+	// The final newline ends the last line, it does not start a new one
+	for i, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if pattern.MatchString(line) {
+			matches = append(matches, GrepMatch{Path: path, Line: i + 1, Text: line})
+		}
+	}
+	return matches
+}
+
+/*Grep searches the given pattern in the tracked files of the current git repository.
+ *If fromHead is true, the files are read from the HEAD commit, otherwise they are read from the working tree
+ *(the files listed in the index, and the untracked files that are not ignored if withUntracked is true).
+ *If there is an error processing the request, it returns this one.
+ */
+func (g *GitObject) Grep(pattern *regexp.Regexp, fromHead, withUntracked bool) ([]GrepMatch, error) {
+	if !g.isAccessible() {
+		return nil, g.accessible
+	}
+	if fromHead {
+		return g.grepHead(pattern)
+	}
+	return g.grepWorkdir(pattern, withUntracked)
+}
+
+/*grepHead searches the given pattern in the files of the HEAD commit
+ */
+func (g *GitObject) grepHead(pattern *regexp.Regexp) ([]GrepMatch, error) {
+	repositoryHead, err := g.repository.Head()
+	if err != nil {
+		return nil, err
+	}
+	headCommit, err := g.repository.LookupCommit(repositoryHead.Target())
+	if err != nil {
+		return nil, err
+	}
+	headTree, err := headCommit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var matches []GrepMatch
+	var walkErr error
+	err = headTree.Walk(func(root string, entry *git.TreeEntry) int {
+		// Skip directories, submodules and symbolic links
+		if entry.Type != git.ObjectBlob || entry.Filemode == git.FilemodeLink {
+			return 0
+		}
+		blob, err := g.repository.LookupBlob(entry.Id)
+		if err != nil {
+			walkErr = err
+			return -1
+		}
+		matches = grepContent(pattern, root+entry.Name, blob.Contents(), matches)
+		return 0
+	})
+	if walkErr != nil {
+		return nil, walkErr
+	}
+	return matches, err
+}
+
+/*grepWorkdir searches the given pattern in the files of the working tree
+ */
+func (g *GitObject) grepWorkdir(pattern *regexp.Regexp, withUntracked bool) ([]GrepMatch, error) {
+	currentIndex, err := g.repository.Index()
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	seen := make(map[string]bool)
+	for i := uint(0); i < currentIndex.EntryCount(); i++ {
+		entry, err := currentIndex.EntryByIndex(i)
+		if err != nil {
+			return nil, err
+		}
+		// Skip submodules and symbolic links, and conflicting entries already listed
+		if entry.Mode == git.FilemodeCommit || entry.Mode == git.FilemodeLink || seen[entry.Path] {
+			continue
+		}
+		seen[entry.Path] = true
+		paths = append(paths, entry.Path)
+	}
+	if withUntracked {
+		// The status list does not contain ignored files
+		statusList, err := g.repository.StatusList(&git.StatusOptions{
+			Show:  git.StatusShowWorkdirOnly,
+			Flags: git.StatusOptIncludeUntracked | git.StatusOptRecurseUntrackedDirs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer statusList.Free()
+		entryCount, err := statusList.EntryCount()
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < entryCount; i++ {
+			entry, err := statusList.ByIndex(i)
+			if err != nil {
+				return nil, err
+			}
+			if entry.Status&git.StatusWtNew != 0 {
+				paths = append(paths, entry.IndexToWorkdir.NewFile.Path)
+			}
+		}
+	}
+	var matches []GrepMatch
+	workdir := g.repository.Workdir()
+	for _, path := range paths {
+		// Files deleted from the working tree are ignored
+		content, err := ioutil.ReadFile(filepath.Join(workdir, path))
+		if err != nil {
+			continue
+		}
+		matches = grepContent(pattern, path, content, matches)
+	}
+	return matches, nil
+}
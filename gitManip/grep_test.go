@@ -0,0 +1,71 @@
+package gitManip
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestGrep(t *testing.T) {
+	local := newRepositoryFixture(t)
+	defer os.RemoveAll(local)
+	commitFile(t, local, "main.go", "package main\n\nfunc main() {\n}\n")
+	commitFile(t, local, ".gitignore", "ignored.go\n")
+	ioutil.WriteFile(filepath.Join(local, "main.go"), []byte("package main\n\nfunc main() {\n\tmain()\n}\n"), 0644)
+	ioutil.WriteFile(filepath.Join(local, "untracked.go"), []byte("func main() {}\n"), 0644)
+	ioutil.WriteFile(filepath.Join(local, "ignored.go"), []byte("func main() {}\n"), 0644)
+	pattern := regexp.MustCompile(`main\(\)`)
+
+	matches, err := New(local).Grep(pattern, true, false)
+	if err != nil {
+		t.Fatalf("The search in HEAD failed: %s", err)
+	}
+	if len(matches) != 1 || matches[0].Path != "main.go" || matches[0].Line != 3 {
+		t.Errorf("The matches in HEAD are not correct, got %v.", matches)
+	}
+
+	matches, err = New(local).Grep(pattern, false, false)
+	if err != nil {
+		t.Fatalf("The search in the working tree failed: %s", err)
+	}
+	if len(matches) != 2 || matches[1].Line != 4 || matches[1].Text != "\tmain()" {
+		t.Errorf("The matches in the working tree are not correct, got %v.", matches)
+	}
+
+	matches, err = New(local).Grep(pattern, false, true)
+	if err != nil {
+		t.Fatalf("The search in untracked files failed: %s", err)
+	}
+	if len(matches) != 3 || matches[2].Path != "untracked.go" {
+		t.Errorf("The matches with untracked files are not correct, got %v.", matches)
+	}
+}
+
+func TestGrepContent(t *testing.T) {
+	tests := []struct {
+		pattern string
+		content string
+		lines   []int
+	}{
+		{`^$`, "first\n\nthird\n", []int{2}},
+		{`^$`, "first\nsecond", nil},
+		{`^$`, "", nil},
+		{`^$`, "\n", []int{1}},
+		{`.*`, "first\nsecond\n", []int{1, 2}},
+		{`second$`, "first\r\nsecond\r\n", []int{2}},
+		{`.*`, "binary\x00content\n", nil},
+	}
+	for _, test := range tests {
+		matches := grepContent(regexp.MustCompile(test.pattern), "file", []byte(test.content), nil)
+		lines := make([]int, 0, len(matches))
+		for _, match := range matches {
+			lines = append(lines, match.Line)
+		}
+		if fmt.Sprint(lines) != fmt.Sprint(test.lines) {
+			t.Errorf("The matching lines of %q in %q are not correct, got %v instead of %v.", test.pattern, test.content, lines, test.lines)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package gitManip
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/k0pernicus/goyave/utils"
+	git "gopkg.in/libgit2/git2go.v27"
+)
+
+/*knownHostsFile returns the path of the known hosts file of OpenSSH, for the current user
+ */
+func knownHostsFile() string {
+	return filepath.Join(utils.GetUserHomeDir(), ".ssh", "known_hosts")
+}
+
+/*defaultSSHPort is the port used by SSH when the URL of the remote does not give one
+ */
+const defaultSSHPort = 22
+
+/*remotePort returns the SSH port of the given remote URL: the one of an "ssh://host:port/path" URL, or the
+ *default port (for a scp-like "user@host:path" URL too)
+ */
+func remotePort(remoteURL string) int {
+	if u, err := url.Parse(remoteURL); err == nil && u.Port() != "" {
+		if port, err := strconv.Atoi(u.Port()); err == nil {
+			return port
+		}
+	}
+	return defaultSSHPort
+}
+
+/*knownHostsName returns the name of the given host in a known hosts file: the hostname for the default port, and
+ *"[hostname]:port" for the other ones
+ */
+func knownHostsName(hostname string, port int) string {
+	if port == defaultSSHPort {
+		return hostname
+	}
+	return fmt.Sprintf("[%s]:%d", hostname, port)
+}
+
+/*matchHostPattern returns if the given host and port match a host pattern of a known hosts file: a plain or hashed
+ *("|1|salt|hash") hostname, or a pattern using '*' and '?'.
+ *A pattern like "[host]:2222" only matches this port, and a pattern without port only matches the default port.
+ */
+func matchHostPattern(pattern, hostname string, port int) bool {
+	if strings.HasPrefix(pattern, "|1|") {
+		parts := strings.Split(pattern[len("|1|"):], "|")
+		if len(parts) != 2 {
+			return false
+		}
+		salt, err := base64.StdEncoding.DecodeString(parts[0])
+		if err != nil {
+			return false
+		}
+		hash, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return false
+		}
+		mac := hmac.New(sha1.New, salt)
+		mac.Write([]byte(knownHostsName(hostname, port)))
+		return hmac.Equal(mac.Sum(nil), hash)
+	}
+	patternPort := defaultSSHPort
+	if strings.HasPrefix(pattern, "[") {
+		end := strings.Index(pattern, "]:")
+		if end == -1 {
+			return false
+		}
+		var err error
+		if patternPort, err = strconv.Atoi(pattern[end+len("]:"):]); err != nil {
+			return false
+		}
+		pattern = pattern[1:end]
+	}
+	if patternPort != port {
+		return false
+	}
+	matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(hostname))
+	return err == nil && matched
+}
+
+/*matchHosts returns if the given host and port match the comma-separated host patterns of a known hosts file line.
+ *A negated pattern ("!host") that matches excludes the host.
+ */
+func matchHosts(patterns, hostname string, port int) bool {
+	matched := false
+	for _, pattern := range strings.Split(patterns, ",") {
+		if strings.HasPrefix(pattern, "!") {
+			if matchHostPattern(pattern[1:], hostname, port) {
+				return false
+			}
+			continue
+		}
+		if matchHostPattern(pattern, hostname, port) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+/*matchHostkey returns if the given key (encoded in base64, like in a known hosts file) is the one of the
+ *certificate, using the strongest hash given by libgit2
+ */
+func matchHostkey(encodedKey string, hostkey git.HostkeyCertificate) bool {
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return false
+	}
+	switch {
+	case hostkey.Kind&git.HostkeySHA1 != 0:
+		hash := sha1.Sum(key)
+		return bytes.Equal(hash[:], hostkey.HashSHA1[:])
+	case hostkey.Kind&git.HostkeyMD5 != 0:
+		hash := md5.Sum(key)
+		return bytes.Equal(hash[:], hostkey.HashMD5[:])
+	}
+	return false
+}
+
+/*checkHostkey checks the SSH host key of the given host, reached on the given port, against the known hosts file.
+ *It returns an error if the host is unknown, if his key does not match the known ones, or if his key has been
+ *revoked.
+ */
+func checkHostkey(hostname string, port int, hostkey git.HostkeyCertificate, knownHostsPath string) error {
+	name := knownHostsName(hostname, port)
+	file, err := os.Open(knownHostsPath)
+	if err != nil {
+		return fmt.Errorf("can't check the host key of %s: %s", name, err)
+	}
+	defer file.Close()
+	known := false
+	accepted := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		marker := ""
+		if strings.HasPrefix(fields[0], "@") {
+			marker, fields = fields[0], fields[1:]
+		}
+		// A line is "hosts keytype key [comment]"
+		if len(fields) < 3 || !matchHosts(fields[0], hostname, port) {
+			continue
+		}
+		switch marker {
+		case "@revoked":
+			if matchHostkey(fields[2], hostkey) {
+				return fmt.Errorf("the host key of %s has been revoked", name)
+			}
+		case "":
+			known = true
+			if matchHostkey(fields[2], hostkey) {
+				accepted = true
+			}
+		}
+		// Certificate authorities are not supported: their lines are ignored
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("can't read %s: %s", knownHostsPath, err)
+	}
+	switch {
+	case accepted:
+		return nil
+	case known:
+		return fmt.Errorf("the host key of %s does not match the one of %s - someone may be doing something nasty", name, knownHostsPath)
+	}
+	return fmt.Errorf("%s is not a known host - connect to it once using ssh, to add his key to %s", name, knownHostsPath)
+}
@@ -0,0 +1,97 @@
+package gitManip
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	git "gopkg.in/libgit2/git2go.v27"
+)
+
+func TestCheckHostkey(t *testing.T) {
+	directory, err := ioutil.TempDir("", "goyave-known-hosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	githubKey := []byte("github host key")
+	otherKey := []byte("other host key")
+	revokedKey := []byte("revoked host key")
+	encode := func(key []byte) string {
+		return base64.StdEncoding.EncodeToString(key)
+	}
+	hash := func(name string) string {
+		salt := []byte("salt")
+		mac := hmac.New(sha1.New, salt)
+		mac.Write([]byte(name))
+		return "|1|" + encode(salt) + "|" + encode(mac.Sum(nil))
+	}
+	knownHosts := filepath.Join(directory, "known_hosts")
+	ioutil.WriteFile(knownHosts, []byte(
+		"# comment\n"+
+			"github.com,140.82.121.4 ssh-ed25519 "+encode(githubKey)+"\n"+
+			"[gitlab.example.com]:2222 ssh-rsa "+encode(otherKey)+" comment\n"+
+			hash("hashed.example.com")+" ssh-ed25519 "+encode(otherKey)+"\n"+
+			hash("[hashed.example.com]:2222")+" ssh-ed25519 "+encode(githubKey)+"\n"+
+			"*.example.org,!evil.example.org ssh-rsa "+encode(otherKey)+"\n"+
+			"@revoked * ssh-rsa "+encode(revokedKey)+"\n"), 0644)
+	sha1Certificate := func(key []byte) git.HostkeyCertificate {
+		return git.HostkeyCertificate{Kind: git.HostkeySHA1, HashSHA1: sha1.Sum(key)}
+	}
+	tests := []struct {
+		hostname string
+		port     int
+		hostkey  git.HostkeyCertificate
+		accepted bool
+	}{
+		{"github.com", 22, sha1Certificate(githubKey), true},
+		{"github.com", 22, git.HostkeyCertificate{Kind: git.HostkeyMD5, HashMD5: md5.Sum(githubKey)}, true},
+		{"github.com", 22, sha1Certificate(otherKey), false},
+		{"github.com", 2222, sha1Certificate(githubKey), false},
+		{"gitlab.example.com", 2222, sha1Certificate(otherKey), true},
+		{"gitlab.example.com", 22, sha1Certificate(otherKey), false},
+		{"gitlab.example.com", 2200, sha1Certificate(otherKey), false},
+		{"hashed.example.com", 22, sha1Certificate(otherKey), true},
+		{"hashed.example.com", 22, sha1Certificate(githubKey), false},
+		{"hashed.example.com", 2222, sha1Certificate(githubKey), true},
+		{"hashed.example.com", 2222, sha1Certificate(otherKey), false},
+		{"git.example.org", 22, sha1Certificate(otherKey), true},
+		{"evil.example.org", 22, sha1Certificate(otherKey), false},
+		{"unknown.com", 22, sha1Certificate(githubKey), false},
+		{"git.example.org", 22, sha1Certificate(revokedKey), false},
+	}
+	for _, test := range tests {
+		err := checkHostkey(test.hostname, test.port, test.hostkey, knownHosts)
+		if test.accepted && err != nil {
+			t.Errorf("The host key of %s:%d should be accepted, got the error %s.", test.hostname, test.port, err)
+		}
+		if !test.accepted && err == nil {
+			t.Errorf("The host key of %s:%d should be rejected.", test.hostname, test.port)
+		}
+	}
+	if err := checkHostkey("github.com", 22, sha1Certificate(githubKey), filepath.Join(directory, "missing")); err == nil {
+		t.Error("A host key should be rejected if the known hosts file is missing.")
+	}
+}
+
+func TestRemotePort(t *testing.T) {
+	tests := []struct {
+		url  string
+		port int
+	}{
+		{"git@github.com:k0pernicus/goyave.git", 22},
+		{"ssh://git@github.com/k0pernicus/goyave.git", 22},
+		{"ssh://git@gitlab.example.com:2222/goyave.git", 2222},
+		{"/src/goyave", 22},
+	}
+	for _, test := range tests {
+		if port := remotePort(test.url); port != test.port {
+			t.Errorf("The port of %s is not correct, got %d instead of %d.", test.url, port, test.port)
+		}
+	}
+}
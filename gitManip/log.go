@@ -0,0 +1,108 @@
+package gitManip
+
+import (
+	"strings"
+	"time"
+
+	git "gopkg.in/libgit2/git2go.v27"
+)
+
+/*CurrentUserAuthor is the author name to use to get only the commits of the current git user
+ */
+const CurrentUserAuthor = "me"
+
+/*CommitInfo contains informations about a commit
+ *
+ *The structure is:
+ *	ID:
+ *		The commit hash.
+ *	Summary:
+ *		The first line of the commit message.
+ *	Author:
+ *		The name of the author.
+ *	Email:
+ *		The email of the author.
+ *	Date:
+ *		The author date.
+ */
+type CommitInfo struct {
+	ID      string
+	Summary string
+	Author  string
+	Email   string
+	Date    time.Time
+}
+
+/*matchAuthor returns a function to know if a commit signature matches the given author.
+ *The author is searched (case insensitive) in the name and the email of the signature.
+ *If the author is "me", the signature must match the user name or the user email of the git configuration.
+ */
+func (g *GitObject) matchAuthor(author string) (func(*git.Signature) bool, error) {
+	if author == "" {
+		return func(*git.Signature) bool { return true }, nil
+	}
+	if author != CurrentUserAuthor {
+		author = strings.ToLower(author)
+		return func(signature *git.Signature) bool {
+			return strings.Contains(strings.ToLower(signature.Name), author) || strings.Contains(strings.ToLower(signature.Email), author)
+		}, nil
+	}
+	config, err := g.repository.Config()
+	if err != nil {
+		return nil, err
+	}
+	userName, _ := config.LookupString("user.name")
+	userEmail, _ := config.LookupString("user.email")
+	return func(signature *git.Signature) bool {
+		return (userEmail != "" && strings.EqualFold(signature.Email, userEmail)) || (userName != "" && signature.Name == userName)
+	}, nil
+}
+
+/*Log returns the commits reachable from HEAD, written since the given date by the given author (every author
+ *if empty, the current git user if "me").
+ *The commits are sorted from the newest to the oldest one.
+ *If there is an error processing the request, it returns this one.
+ */
+func (g *GitObject) Log(since time.Time, author string) ([]CommitInfo, error) {
+	if !g.isAccessible() {
+		return nil, g.accessible
+	}
+	// A new repository does not contain any commit
+	headUnborn, err := g.repository.IsHeadUnborn()
+	if err != nil || headUnborn {
+		return nil, err
+	}
+	authorMatches, err := g.matchAuthor(author)
+	if err != nil {
+		return nil, err
+	}
+	walk, err := g.repository.Walk()
+	if err != nil {
+		return nil, err
+	}
+	defer walk.Free()
+	walk.Sorting(git.SortTime)
+	if err := walk.PushHead(); err != nil {
+		return nil, err
+	}
+	var commits []CommitInfo
+	err = walk.Iterate(func(commit *git.Commit) bool {
+		// Commits are sorted by committer date, so the next ones are older
+		if commit.Committer().When.Before(since) {
+			return false
+		}
+		commitAuthor := commit.Author()
+		if commitAuthor.When.Before(since) || !authorMatches(commitAuthor) {
+			return true
+		}
+		commits = append(commits, CommitInfo{
+			ID:      commit.Id().String(),
+			Summary: commit.Summary(),
+			Author:  commitAuthor.Name,
+			Email:   commitAuthor.Email,
+			Date:    commitAuthor.When,
+		})
+		return true
+	})
+	return commits, err
+}
@@ -0,0 +1,385 @@
+package gitManip
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/k0pernicus/goyave/traces"
+	git "gopkg.in/libgit2/git2go.v27"
+)
+
+/*maxCredentialsAttempts is the number of times a remote can ask for credentials, before giving up.
+ *libgit2 asks again and again for credentials while the authentication fails.
+ */
+const maxCredentialsAttempts = 3
+
+/*RefUpdate represents a reference updated by a remote operation
+ *
+ *The structure is:
+ *	Name:
+ *		The name of the reference (like "refs/remotes/origin/master").
+ *	Old:
+ *		The previous target of the reference (zero if the reference is new).
+ *	New:
+ *		The new target of the reference.
+ */
+type RefUpdate struct {
+	Name string
+	Old  string
+	New  string
+}
+
+/*FetchResult contains informations about a fetch of the current git repository
+ *
+ *The structure is:
+ *	Remotes:
+ *		The names of the fetched remotes.
+ *	UpdatedRefs:
+ *		The references updated by the fetch.
+ */
+type FetchResult struct {
+	Remotes     []string
+	UpdatedRefs []RefUpdate
+}
+
+/*newRemoteCallbacks returns the callbacks to use to talk to the remote with the given URL.
+ *The credentials are asked to the SSH agent, the SSH host key is checked for the port of the URL, and each updated
+ *reference is appended to updates (if not nil).
+ */
+func newRemoteCallbacks(remoteURL string, updates *[]RefUpdate) git.RemoteCallbacks {
+	port := remotePort(remoteURL)
+	attempts := 0
+	return git.RemoteCallbacks{
+		CredentialsCallback: func(url string, usernameFromURL string, allowedTypes git.CredType) (git.ErrorCode, *git.Cred) {
+			attempts++
+			if attempts > maxCredentialsAttempts || allowedTypes&git.CredTypeSshKey == 0 {
+				return git.ErrGeneric, nil
+			}
+			ret, cred := git.NewCredSshKeyFromAgent(usernameFromURL)
+			return git.ErrorCode(ret), &cred
+		},
+		// libgit2 does not check SSH host keys by itself: they are checked against the known hosts of OpenSSH
+		CertificateCheckCallback: func(cert *git.Certificate, valid bool, hostname string) git.ErrorCode {
+			if cert.Kind == git.CertificateHostkey {
+				if err := checkHostkey(hostname, port, cert.Hostkey, knownHostsFile()); err != nil {
+					traces.WarningTracer.Println(err)
+					return git.ErrCertificate
+				}
+				return git.ErrOk
+			}
+			if valid {
+				return git.ErrOk
+			}
+			return git.ErrCertificate
+		},
+		UpdateTipsCallback: func(refname string, a *git.Oid, b *git.Oid) git.ErrorCode {
+			if updates != nil {
+				*updates = append(*updates, RefUpdate{Name: refname, Old: a.String(), New: b.String()})
+			}
+			return git.ErrOk
+		},
+	}
+}
+
+/*Fetch fetches the given remotes of the current git repository (all the remotes, if remoteNames is empty).
+ *If there is an error processing the request, it returns this one.
+ */
+func (g *GitObject) Fetch(remoteNames []string) (*FetchResult, error) {
+	if !g.isAccessible() {
+		return nil, g.accessible
+	}
+	if len(remoteNames) == 0 {
+		names, err := g.repository.Remotes.List()
+		if err != nil {
+			return nil, err
+		}
+		remoteNames = names
+	}
+	result := &FetchResult{}
+	for _, remoteName := range remoteNames {
+		remote, err := g.repository.Remotes.Lookup(remoteName)
+		if err != nil {
+			return result, fmt.Errorf("can't lookup the remote %s: %s", remoteName, err)
+		}
+		fetchOptions := &git.FetchOptions{
+			RemoteCallbacks: newRemoteCallbacks(remote.Url(), &result.UpdatedRefs),
+		}
+		err = remote.Fetch([]string{}, fetchOptions, "")
+		remote.Free()
+		if err != nil {
+			return result, fmt.Errorf("can't fetch the remote %s: %s", remoteName, err)
+		}
+		result.Remotes = append(result.Remotes, remoteName)
+	}
+	return result, nil
+}
+
+/*PullStatus represents the result of a fast-forward of a git repository
+ */
+type PullStatus int
+
+const (
+	// PullUpdated means the current branch has been fast-forwarded
+	PullUpdated PullStatus = iota
+	// PullUpToDate means the current branch is not behind his upstream branch
+	PullUpToDate
+	// PullSkippedDirty means the working tree contains changes
+	PullSkippedDirty
+	// PullSkippedDiverged means the current branch and his upstream branch have diverged
+	PullSkippedDiverged
+	// PullSkippedDetached means the HEAD of the repository is detached
+	PullSkippedDetached
+	// PullSkippedNoUpstream means the current branch has no upstream branch
+	PullSkippedNoUpstream
+)
+
+/*Map to match the PullStatus enum type with a string
+ */
+var pullStatusToString = map[PullStatus]string{
+	PullUpdated:           "updated",
+	PullUpToDate:          "up to date",
+	PullSkippedDirty:      "skipped: the working tree is dirty",
+	PullSkippedDiverged:   "skipped: the branch has diverged from his upstream branch",
+	PullSkippedDetached:   "skipped: the HEAD is detached",
+	PullSkippedNoUpstream: "skipped: the branch has no upstream branch",
+}
+
+/*String returns a readable description of the pull status
+ */
+func (s PullStatus) String() string {
+	return pullStatusToString[s]
+}
+
+/*PullResult contains informations about a fast-forward of the current git repository
+ *
+ *The structure is:
+ *	Status:
+ *		What has been done.
+ *	Branch:
+ *		The name of the current branch.
+ *	Old:
+ *		The previous target of the branch.
+ *	New:
+ *		The new target of the branch (the same as Old if the branch has not been updated).
+ *	Commits:
+ *		The number of commits behind the upstream branch, before the fast-forward.
+ */
+type PullResult struct {
+	Status  PullStatus
+	Branch  string
+	Old     string
+	New     string
+	Commits int
+}
+
+/*FastForward fast-forwards the current branch to his upstream branch, and checks out the new tree.
+ *The branch is updated only if the working tree is clean, and if the branch is strictly behind his upstream
+ *branch - otherwise, the returned status explains why the repository has been skipped.
+ *If there is an error processing the request, it returns this one.
+ */
+func (g *GitObject) FastForward() (*PullResult, error) {
+	if !g.isAccessible() {
+		return nil, g.accessible
+	}
+	headDetached, err := g.repository.IsHeadDetached()
+	if err != nil {
+		return nil, err
+	}
+	if headDetached {
+		return &PullResult{Status: PullSkippedDetached}, nil
+	}
+	repositoryHead, err := g.repository.Head()
+	if err != nil {
+		return nil, err
+	}
+	result := &PullResult{
+		Branch: repositoryHead.Shorthand(),
+		Old:    repositoryHead.Target().String(),
+	}
+	result.New = result.Old
+	upstream, err := repositoryHead.Branch().Upstream()
+	if err != nil {
+		result.Status = PullSkippedNoUpstream
+		return result, nil
+	}
+	commitsAhead, commitsBehind, err := g.repository.AheadBehind(repositoryHead.Target(), upstream.Target())
+	if err != nil {
+		return nil, err
+	}
+	result.Commits = commitsBehind
+	switch {
+	case commitsBehind == 0:
+		result.Status = PullUpToDate
+		return result, nil
+	case commitsAhead != 0:
+		result.Status = PullSkippedDiverged
+		return result, nil
+	}
+	dirty, err := g.IsDirty()
+	if err != nil {
+		return nil, err
+	}
+	if dirty {
+		result.Status = PullSkippedDirty
+		return result, nil
+	}
+	// Update the working tree before moving the branch, to keep the repository clean if the checkout fails
+	upstreamCommit, err := g.repository.LookupCommit(upstream.Target())
+	if err != nil {
+		return nil, err
+	}
+	upstreamTree, err := upstreamCommit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	if err := g.repository.CheckoutTree(upstreamTree, &git.CheckoutOpts{Strategy: git.CheckoutSafe}); err != nil {
+		return nil, err
+	}
+	if _, err := repositoryHead.SetTarget(upstream.Target(), fmt.Sprintf("goyave: fast-forward to %s", upstream.Shorthand())); err != nil {
+		return nil, err
+	}
+	result.Status = PullUpdated
+	result.New = upstream.Target().String()
+	return result, nil
+}
+
+/*PushStatus represents the result of a push of a git repository
+ */
+type PushStatus int
+
+const (
+	// PushPushed means the current branch has been pushed
+	PushPushed PushStatus = iota
+	// PushDryRun means the current branch would have been pushed
+	PushDryRun
+	// PushUpToDate means the current branch is not ahead of his upstream branch
+	PushUpToDate
+	// PushSkippedBehind means the current branch is behind his upstream branch, and would need a force-push
+	PushSkippedBehind
+	// PushSkippedDetached means the HEAD of the repository is detached
+	PushSkippedDetached
+	// PushSkippedNoUpstream means the current branch has no upstream branch
+	PushSkippedNoUpstream
+)
+
+/*Map to match the PushStatus enum type with a string
+ */
+var pushStatusToString = map[PushStatus]string{
+	PushPushed:            "pushed",
+	PushDryRun:            "would be pushed",
+	PushUpToDate:          "up to date",
+	PushSkippedBehind:     "skipped: the branch is behind his upstream branch",
+	PushSkippedDetached:   "skipped: the HEAD is detached",
+	PushSkippedNoUpstream: "skipped: the branch has no upstream branch",
+}
+
+/*String returns a readable description of the push status
+ */
+func (s PushStatus) String() string {
+	return pushStatusToString[s]
+}
+
+/*PushResult contains informations about a push of the current git repository
+ *
+ *The structure is:
+ *	Status:
+ *		What has been done.
+ *	Branch:
+ *		The name of the current branch.
+ *	Remote:
+ *		The name of the remote of the upstream branch.
+ *	Upstream:
+ *		The name of the remote branch (like "refs/heads/master").
+ *	Commits:
+ *		The number of commits ahead of the upstream branch, before the push.
+ */
+type PushResult struct {
+	Status   PushStatus
+	Branch   string
+	Remote   string
+	Upstream string
+	Commits  int
+}
+
+/*Push pushes the current branch to his upstream branch, if this one is ahead and not behind.
+ *The push is never forced: a branch that is behind his upstream branch is skipped.
+ *If dryRun is true, nothing is pushed.
+ *If there is an error processing the request, it returns this one.
+ */
+func (g *GitObject) Push(dryRun bool) (*PushResult, error) {
+	if !g.isAccessible() {
+		return nil, g.accessible
+	}
+	headDetached, err := g.repository.IsHeadDetached()
+	if err != nil {
+		return nil, err
+	}
+	if headDetached {
+		return &PushResult{Status: PushSkippedDetached}, nil
+	}
+	repositoryHead, err := g.repository.Head()
+	if err != nil {
+		return nil, err
+	}
+	result := &PushResult{Branch: repositoryHead.Shorthand()}
+	upstream, err := repositoryHead.Branch().Upstream()
+	if err != nil {
+		result.Status = PushSkippedNoUpstream
+		return result, nil
+	}
+	commitsAhead, commitsBehind, err := g.repository.AheadBehind(repositoryHead.Target(), upstream.Target())
+	if err != nil {
+		return nil, err
+	}
+	result.Commits = commitsAhead
+	switch {
+	case commitsBehind != 0:
+		result.Status = PushSkippedBehind
+		return result, nil
+	case commitsAhead == 0:
+		result.Status = PushUpToDate
+		return result, nil
+	}
+	// Get the remote and the remote branch, from the branch configuration
+	config, err := g.repository.Config()
+	if err != nil {
+		return nil, err
+	}
+	if result.Remote, err = config.LookupString(fmt.Sprintf("branch.%s.remote", result.Branch)); err != nil {
+		return nil, err
+	}
+	if result.Upstream, err = config.LookupString(fmt.Sprintf("branch.%s.merge", result.Branch)); err != nil {
+		return nil, err
+	}
+	remote, err := g.repository.Remotes.Lookup(result.Remote)
+	if err != nil {
+		return nil, fmt.Errorf("can't lookup the remote %s: %s", result.Remote, err)
+	}
+	defer remote.Free()
+	if dryRun {
+		result.Status = PushDryRun
+		return result, nil
+	}
+	var rejection string
+	pushURL := remote.PushUrl()
+	if pushURL == "" {
+		pushURL = remote.Url()
+	}
+	callbacks := newRemoteCallbacks(pushURL, nil)
+	callbacks.PushUpdateReferenceCallback = func(refname string, status string) git.ErrorCode {
+		if status != "" {
+			rejection = fmt.Sprintf("%s has been rejected: %s", refname, status)
+		}
+		return git.ErrOk
+	}
+	// The refspec does not start with '+', so the push can't be forced
+	refspec := fmt.Sprintf("%s:%s", repositoryHead.Name(), result.Upstream)
+	if err := remote.Push([]string{refspec}, &git.PushOptions{RemoteCallbacks: callbacks}); err != nil {
+		return nil, fmt.Errorf("can't push to the remote %s: %s", result.Remote, err)
+	}
+	if rejection != "" {
+		return nil, errors.New(rejection)
+	}
+	result.Status = PushPushed
+	return result, nil
+}
@@ -0,0 +1,269 @@
+package gitManip
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/*gitCommand returns a git command to run in the given directory, with the identity and the configuration of the
+ *tests
+ */
+func gitCommand(dir string, args ...string) *exec.Cmd {
+	args = append([]string{"-c", "user.name=goyave", "-c", "user.email=goyave@example.com", "-c", "init.defaultBranch=master"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	return cmd
+}
+
+/*runGit runs a git command in the given directory, and returns his output
+ */
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	output, err := gitCommand(dir, args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %s\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+/*commitFile writes a file in the given repository, and commits it
+ */
+func commitFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", name)
+	runGit(t, dir, "commit", "-m", "update "+name)
+}
+
+/*newRepositoryFixture creates a repository with a single commit, and without remote.
+ *It returns the path of the repository.
+ */
+func newRepositoryFixture(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	dir, err := ioutil.TempDir("", "goyave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "init")
+	commitFile(t, dir, "README", "first")
+	return dir
+}
+
+/*newRemoteFixture creates a bare repository (used as remote), and two clones of it.
+ *It returns the root directory of the fixture, and the paths of the two clones.
+ */
+func newRemoteFixture(t *testing.T) (string, string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	root, err := ioutil.TempDir("", "goyave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed := filepath.Join(root, "seed")
+	bare := filepath.Join(root, "remote.git")
+	local := filepath.Join(root, "local")
+	other := filepath.Join(root, "other")
+	os.Mkdir(seed, 0755)
+	runGit(t, seed, "init")
+	commitFile(t, seed, "README", "first")
+	runGit(t, root, "clone", "--bare", seed, bare)
+	runGit(t, root, "clone", bare, local)
+	runGit(t, root, "clone", bare, other)
+	return root, local, other
+}
+
+func TestFetchUpdatesRemoteReferences(t *testing.T) {
+	root, local, other := newRemoteFixture(t)
+	defer os.RemoveAll(root)
+	commitFile(t, other, "README", "second")
+	runGit(t, other, "push", "origin", "HEAD")
+	expected := runGit(t, other, "rev-parse", "HEAD")
+	branch := runGit(t, other, "rev-parse", "--abbrev-ref", "HEAD")
+
+	result, err := New(local).Fetch(nil)
+	if err != nil {
+		t.Fatalf("The fetch failed: %s", err)
+	}
+	if len(result.Remotes) != 1 || result.Remotes[0] != "origin" {
+		t.Errorf("The fetched remotes are not correct, got %v instead of %v.", result.Remotes, []string{"origin"})
+	}
+	found := false
+	for _, ref := range result.UpdatedRefs {
+		if ref.Name == "refs/remotes/origin/"+branch {
+			found = true
+			if ref.New != expected {
+				t.Errorf("The new target of %s is not correct, got %s instead of %s.", ref.Name, ref.New, expected)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("The reference refs/remotes/origin/%s has not been updated, got %v.", branch, result.UpdatedRefs)
+	}
+	if tracking := runGit(t, local, "rev-parse", "origin/"+branch); tracking != expected {
+		t.Errorf("The remote tracking branch has not been updated, got %s instead of %s.", tracking, expected)
+	}
+}
+
+func TestFetchUpToDate(t *testing.T) {
+	root, local, _ := newRemoteFixture(t)
+	defer os.RemoveAll(root)
+	result, err := New(local).Fetch([]string{"origin"})
+	if err != nil {
+		t.Fatalf("The fetch failed: %s", err)
+	}
+	if len(result.UpdatedRefs) != 0 {
+		t.Errorf("No reference should be updated, got %v.", result.UpdatedRefs)
+	}
+}
+
+func TestFetchUnknownRemote(t *testing.T) {
+	root, local, _ := newRemoteFixture(t)
+	defer os.RemoveAll(root)
+	if _, err := New(local).Fetch([]string{"unknown"}); err == nil {
+		t.Error("Fetching an unknown remote should fail.")
+	}
+}
+
+func TestFetchMissingRepository(t *testing.T) {
+	root, _, _ := newRemoteFixture(t)
+	defer os.RemoveAll(root)
+	if _, err := New(filepath.Join(root, "missing")).Fetch(nil); err == nil {
+		t.Error("Fetching a missing repository should fail.")
+	}
+}
+
+func TestFastForward(t *testing.T) {
+	root, local, other := newRemoteFixture(t)
+	defer os.RemoveAll(root)
+	commitFile(t, other, "README", "second")
+	runGit(t, other, "push", "origin", "HEAD")
+	expected := runGit(t, other, "rev-parse", "HEAD")
+	runGit(t, local, "fetch")
+
+	result, err := New(local).FastForward()
+	if err != nil {
+		t.Fatalf("The fast-forward failed: %s", err)
+	}
+	if result.Status != PullUpdated {
+		t.Errorf("The status is not correct, got '%s' instead of '%s'.", result.Status, PullUpdated)
+	}
+	if result.Commits != 1 {
+		t.Errorf("The number of commits behind is not correct, got %d instead of %d.", result.Commits, 1)
+	}
+	if head := runGit(t, local, "rev-parse", "HEAD"); head != expected {
+		t.Errorf("The HEAD has not been updated, got %s instead of %s.", head, expected)
+	}
+	if content, _ := ioutil.ReadFile(filepath.Join(local, "README")); string(content) != "second" {
+		t.Errorf("The working tree has not been updated, got '%s' instead of '%s'.", content, "second")
+	}
+	if status := runGit(t, local, "status", "--porcelain"); status != "" {
+		t.Errorf("The working tree should be clean, got '%s'.", status)
+	}
+}
+
+func TestFastForwardSkipped(t *testing.T) {
+	root, local, other := newRemoteFixture(t)
+	defer os.RemoveAll(root)
+	commitFile(t, other, "README", "second")
+	runGit(t, other, "push", "origin", "HEAD")
+	runGit(t, local, "fetch")
+
+	// Dirty working tree
+	ioutil.WriteFile(filepath.Join(local, "README"), []byte("dirty"), 0644)
+	result, err := New(local).FastForward()
+	if err != nil {
+		t.Fatalf("The fast-forward failed: %s", err)
+	}
+	if result.Status != PullSkippedDirty {
+		t.Errorf("The status is not correct, got '%s' instead of '%s'.", result.Status, PullSkippedDirty)
+	}
+	runGit(t, local, "checkout", "--", "README")
+
+	// Diverged branches
+	commitFile(t, local, "LOCAL", "local")
+	if result, _ = New(local).FastForward(); result.Status != PullSkippedDiverged {
+		t.Errorf("The status is not correct, got '%s' instead of '%s'.", result.Status, PullSkippedDiverged)
+	}
+
+	// Detached HEAD
+	runGit(t, local, "checkout", "--detach")
+	if result, _ = New(local).FastForward(); result.Status != PullSkippedDetached {
+		t.Errorf("The status is not correct, got '%s' instead of '%s'.", result.Status, PullSkippedDetached)
+	}
+
+	// No upstream branch
+	runGit(t, local, "checkout", "-b", "topic")
+	if result, _ = New(local).FastForward(); result.Status != PullSkippedNoUpstream {
+		t.Errorf("The status is not correct, got '%s' instead of '%s'.", result.Status, PullSkippedNoUpstream)
+	}
+}
+
+func TestPush(t *testing.T) {
+	root, local, _ := newRemoteFixture(t)
+	defer os.RemoveAll(root)
+	commitFile(t, local, "LOCAL", "local")
+	expected := runGit(t, local, "rev-parse", "HEAD")
+	branch := runGit(t, local, "rev-parse", "--abbrev-ref", "HEAD")
+	bare := filepath.Join(root, "remote.git")
+
+	result, err := New(local).Push(true)
+	if err != nil {
+		t.Fatalf("The dry-run push failed: %s", err)
+	}
+	if result.Status != PushDryRun || result.Commits != 1 {
+		t.Errorf("The dry-run result is not correct, got '%s' (%d commits).", result.Status, result.Commits)
+	}
+	if remoteHead := runGit(t, bare, "rev-parse", branch); remoteHead == expected {
+		t.Error("The dry-run push should not update the remote repository.")
+	}
+
+	result, err = New(local).Push(false)
+	if err != nil {
+		t.Fatalf("The push failed: %s", err)
+	}
+	if result.Status != PushPushed || result.Remote != "origin" || result.Upstream != "refs/heads/"+branch {
+		t.Errorf("The push result is not correct, got %+v.", result)
+	}
+	if remoteHead := runGit(t, bare, "rev-parse", branch); remoteHead != expected {
+		t.Errorf("The remote branch has not been updated, got %s instead of %s.", remoteHead, expected)
+	}
+
+	if result, _ = New(local).Push(false); result.Status != PushUpToDate {
+		t.Errorf("The status is not correct, got '%s' instead of '%s'.", result.Status, PushUpToDate)
+	}
+}
+
+func TestPushSkipped(t *testing.T) {
+	root, local, other := newRemoteFixture(t)
+	defer os.RemoveAll(root)
+	commitFile(t, other, "README", "second")
+	runGit(t, other, "push", "origin", "HEAD")
+	runGit(t, local, "fetch")
+	commitFile(t, local, "LOCAL", "local")
+
+	// Diverged branches must not be force-pushed
+	result, err := New(local).Push(false)
+	if err != nil {
+		t.Fatalf("The push failed: %s", err)
+	}
+	if result.Status != PushSkippedBehind {
+		t.Errorf("The status is not correct, got '%s' instead of '%s'.", result.Status, PushSkippedBehind)
+	}
+
+	// No upstream branch
+	runGit(t, local, "checkout", "-b", "topic")
+	if result, _ = New(local).Push(false); result.Status != PushSkippedNoUpstream {
+		t.Errorf("The status is not correct, got '%s' instead of '%s'.", result.Status, PushSkippedNoUpstream)
+	}
+}
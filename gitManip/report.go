@@ -0,0 +1,213 @@
+package gitManip
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	git "gopkg.in/libgit2/git2go.v27"
+)
+
+/*StatusSchemaVersion is the version of the machine-readable status schema (StatusReport, and the porcelain format).
+ *It is increased each time a field is removed, or changes of meaning - new fields can be added without a new version.
+ */
+const StatusSchemaVersion = 1
+
+/*Map to match the RepositoryState enum type with a stable identifier, for the machine-readable formats
+ */
+var repositoryStateToID = map[git.RepositoryState]string{
+	git.RepositoryStateNone:                 "none",
+	git.RepositoryStateMerge:                "merge",
+	git.RepositoryStateRevert:               "revert",
+	git.RepositoryStateCherrypick:           "cherry-pick",
+	git.RepositoryStateBisect:               "bisect",
+	git.RepositoryStateRebase:               "rebase",
+	git.RepositoryStateRebaseInteractive:    "rebase-interactive",
+	git.RepositoryStateRebaseMerge:          "rebase-merge",
+	git.RepositoryStateApplyMailbox:         "apply-mailbox",
+	git.RepositoryStateApplyMailboxOrRebase: "apply-mailbox-or-rebase",
+}
+
+/*RebaseProgress contains the progress of a rebase
+ *
+ *The structure is:
+ *	Step:
+ *		The current step.
+ *	Total:
+ *		The number of steps.
+ */
+type RebaseProgress struct {
+	Step  int `json:"step"`
+	Total int `json:"total"`
+}
+
+/*ChangeReport is the machine-readable representation of a FileChange
+ *
+ *The structure is:
+ *	Path:
+ *		The path of the file, relative to the repository.
+ *	OldPath:
+ *		The previous path of the file, only if the file has been renamed.
+ *	Kind:
+ *		The kind of change: added, deleted, modified, renamed, typechange, untracked or conflicted.
+ *	Area:
+ *		Where the change is: staged, unstaged, untracked or conflicted.
+ */
+type ChangeReport struct {
+	Path    string `json:"path"`
+	OldPath string `json:"old_path,omitempty"`
+	Kind    string `json:"kind"`
+	Area    string `json:"area"`
+}
+
+/*StatusReport is the machine-readable representation of a RepoStatus
+ *
+ *The structure is:
+ *	SchemaVersion:
+ *		The version of the schema (StatusSchemaVersion), only set when the report is printed alone.
+ *	Name:
+ *		The name of the repository.
+ *	Path:
+ *		The path of the repository.
+ *	Branch:
+ *		The name of the current branch (empty if the HEAD is detached, or if the repository has no commit yet).
+ *	Upstream:
+ *		The name of the upstream branch (empty if there is no upstream branch).
+ *	Ahead:
+ *		The number of commits ahead of the upstream branch.
+ *	Behind:
+ *		The number of commits behind the upstream branch.
+ *	Detached:
+ *		Is the HEAD of the repository detached?
+ *	LastCommit:
+ *		The date of the HEAD commit, only if the repository has a commit.
+ *	State:
+ *		The operation in progress: none, merge, revert, cherry-pick, bisect, rebase, rebase-interactive,
+ *		rebase-merge, apply-mailbox or apply-mailbox-or-rebase.
+ *	Rebase:
+ *		The progress of the rebase in progress, if it is known.
+ *	Stashes:
+ *		The number of stashes.
+ *	NeedsAttention:
+ *		Can't the repository be read, or has it an operation in progress or conflicted files?
+ *	Changes:
+ *		The changed files (never null).
+ *	Error:
+ *		The error that occurred while reading the repository, if any.
+ */
+type StatusReport struct {
+	SchemaVersion  int             `json:"schema_version,omitempty"`
+	Name           string          `json:"name"`
+	Path           string          `json:"path"`
+	Branch         string          `json:"branch"`
+	Upstream       string          `json:"upstream"`
+	Ahead          int             `json:"ahead"`
+	Behind         int             `json:"behind"`
+	Detached       bool            `json:"detached"`
+	LastCommit     *time.Time      `json:"last_commit,omitempty"`
+	State          string          `json:"state"`
+	Rebase         *RebaseProgress `json:"rebase,omitempty"`
+	Stashes        int             `json:"stashes"`
+	NeedsAttention bool            `json:"needs_attention"`
+	Changes        []ChangeReport  `json:"changes"`
+	Error          string          `json:"error,omitempty"`
+}
+
+/*Report returns the machine-readable representation of the status, for the repository with the given name
+ */
+func (s *RepoStatus) Report(name string) StatusReport {
+	report := StatusReport{
+		Name:           name,
+		Path:           s.Path,
+		Branch:         s.Branch,
+		Upstream:       s.Upstream,
+		Ahead:          s.Ahead,
+		Behind:         s.Behind,
+		Detached:       s.Detached,
+		State:          repositoryStateToID[s.state],
+		Stashes:        s.Stashes,
+		NeedsAttention: s.NeedsAttention(),
+		Changes:        []ChangeReport{},
+	}
+	if !s.LastCommit.IsZero() {
+		report.LastCommit = &s.LastCommit
+	}
+	if s.RebaseTotal > 0 {
+		report.Rebase = &RebaseProgress{Step: s.RebaseStep, Total: s.RebaseTotal}
+	}
+	for _, change := range s.Changes {
+		changeReport := ChangeReport{Path: change.Path, Kind: change.Kind.String(), Area: change.Area.String()}
+		if change.OldPath != change.Path {
+			changeReport.OldPath = change.OldPath
+		}
+		report.Changes = append(report.Changes, changeReport)
+	}
+	if s.Err != nil {
+		report.Error = s.Err.Error()
+	}
+	return report
+}
+
+/*porcelainQuote returns the given value as a porcelain field: unchanged, or quoted like a Go string literal
+ *(as git quotes the paths) if it contains a double quote, a backslash, or a control character like a tab or a newline
+ */
+func porcelainQuote(value string) string {
+	if strings.IndexFunc(value, func(r rune) bool { return r == '"' || r == '\\' || unicode.IsControl(r) }) == -1 {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+/*Porcelain returns the report in the porcelain format: one "key value" line per information, and an empty line
+ *at the end.
+ *The lines are, in this order (the optional ones are only printed if needed):
+ *	repository <name> TAB <path>
+ *	branch <name>, or branch (detached), or branch (unborn)
+ *	upstream <name> +<ahead> -<behind>  (optional)
+ *	state <state> [<step>/<total>]
+ *	stashes <count>
+ *	change <area> <kind> <path> [TAB <old path>]  (one line per change)
+ *	attention  (optional)
+ *	error <message>  (optional)
+ *The names, paths, branches and error messages are quoted by porcelainQuote if needed, so a line never contains
+ *another tab or newline.
+ */
+func (r StatusReport) Porcelain() string {
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("repository %s\t%s\n", porcelainQuote(r.Name), porcelainQuote(r.Path)))
+	switch {
+	case r.Detached:
+		buffer.WriteString("branch (detached)\n")
+	case r.Branch == "":
+		buffer.WriteString("branch (unborn)\n")
+	default:
+		buffer.WriteString(fmt.Sprintf("branch %s\n", porcelainQuote(r.Branch)))
+	}
+	if r.Upstream != "" {
+		buffer.WriteString(fmt.Sprintf("upstream %s +%d -%d\n", porcelainQuote(r.Upstream), r.Ahead, r.Behind))
+	}
+	if r.Rebase != nil {
+		buffer.WriteString(fmt.Sprintf("state %s %d/%d\n", r.State, r.Rebase.Step, r.Rebase.Total))
+	} else {
+		buffer.WriteString(fmt.Sprintf("state %s\n", r.State))
+	}
+	buffer.WriteString(fmt.Sprintf("stashes %d\n", r.Stashes))
+	for _, change := range r.Changes {
+		if change.OldPath != "" {
+			buffer.WriteString(fmt.Sprintf("change %s %s %s\t%s\n", change.Area, change.Kind, porcelainQuote(change.Path), porcelainQuote(change.OldPath)))
+		} else {
+			buffer.WriteString(fmt.Sprintf("change %s %s %s\n", change.Area, change.Kind, porcelainQuote(change.Path)))
+		}
+	}
+	if r.NeedsAttention {
+		buffer.WriteString("attention\n")
+	}
+	if r.Error != "" {
+		buffer.WriteString(fmt.Sprintf("error %s\n", porcelainQuote(r.Error)))
+	}
+	buffer.WriteString("\n")
+	return buffer.String()
+}
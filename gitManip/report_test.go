@@ -0,0 +1,71 @@
+package gitManip
+
+import (
+	"errors"
+	"testing"
+
+	git "gopkg.in/libgit2/git2go.v27"
+)
+
+func TestReport(t *testing.T) {
+	status := &RepoStatus{
+		Path:        "/src/goyave",
+		Branch:      "feature",
+		Upstream:    "origin/feature",
+		Ahead:       2,
+		State:       repositoryStateToString[git.RepositoryStateRebaseMerge],
+		RebaseStep:  1,
+		RebaseTotal: 3,
+		Changes: []FileChange{
+			{Path: "main.go", OldPath: "main.go", Kind: ChangeModified, Area: AreaStaged},
+			{Path: "new.go", OldPath: "old.go", Kind: ChangeRenamed, Area: AreaStaged},
+			{Path: "README.md", OldPath: "README.md", Kind: ChangeConflicted, Area: AreaConflicted},
+		},
+		state: git.RepositoryStateRebaseMerge,
+	}
+	report := status.Report("goyave")
+	if report.State != "rebase-merge" || report.Rebase == nil || report.Rebase.Total != 3 || !report.NeedsAttention {
+		t.Errorf("The report is not correct, got %+v.", report)
+	}
+	if report.Changes[0].OldPath != "" || report.Changes[1].OldPath != "old.go" || report.Changes[2].Area != "conflicted" {
+		t.Errorf("The changes of the report are not correct, got %+v.", report.Changes)
+	}
+	expected := "repository goyave\t/src/goyave\n" +
+		"branch feature\n" +
+		"upstream origin/feature +2 -0\n" +
+		"state rebase-merge 1/3\n" +
+		"stashes 0\n" +
+		"change staged modified main.go\n" +
+		"change staged renamed new.go\told.go\n" +
+		"change conflicted conflicted README.md\n" +
+		"attention\n\n"
+	if porcelain := report.Porcelain(); porcelain != expected {
+		t.Errorf("The porcelain output is not correct, got:\n%s", porcelain)
+	}
+}
+
+func TestReportError(t *testing.T) {
+	report := (&RepoStatus{Path: "/src/missing", Err: errors.New("not found")}).Report("missing")
+	if report.Error != "not found" || report.State != "none" || report.Changes == nil || !report.NeedsAttention {
+		t.Errorf("The report of a missing repository is not correct, got %+v.", report)
+	}
+}
+
+func TestReportQuoting(t *testing.T) {
+	status := &RepoStatus{
+		Path:    "/src/go\tyave",
+		Branch:  "main",
+		Changes: []FileChange{{Path: "new\nline.go", OldPath: "quote\".go", Kind: ChangeRenamed, Area: AreaStaged}},
+		Err:     errors.New("first line\nsecond line"),
+	}
+	expected := "repository goyave\t\"/src/go\\tyave\"\n" +
+		"branch main\n" +
+		"state none\n" +
+		"stashes 0\n" +
+		"change staged renamed \"new\\nline.go\"\t\"quote\\\".go\"\n" +
+		"attention\n" +
+		"error \"first line\\nsecond line\"\n\n"
+	if porcelain := status.Report("goyave").Porcelain(); porcelain != expected {
+		t.Errorf("The porcelain output is not correct, got:\n%s", porcelain)
+	}
+}
@@ -0,0 +1,116 @@
+package gitManip
+
+import (
+	"regexp"
+	"time"
+
+	git "gopkg.in/libgit2/git2go.v27"
+)
+
+/*stashBranchRegexp matches the branch name in a stash message, like "WIP on master: ..." or "On master: ..."
+ */
+var stashBranchRegexp = regexp.MustCompile(`^(?:WIP on|On) ([^:]+):`)
+
+/*DiffStat contains the statistics of a diff
+ *
+ *The structure is:
+ *	FilesChanged:
+ *		The number of changed files.
+ *	Insertions:
+ *		The number of inserted lines.
+ *	Deletions:
+ *		The number of deleted lines.
+ */
+type DiffStat struct {
+	FilesChanged int
+	Insertions   int
+	Deletions    int
+}
+
+/*StashInfo contains informations about a stash
+ *
+ *The structure is:
+ *	Index:
+ *		The index of the stash (stash@{Index}).
+ *	Message:
+ *		The message of the stash.
+ *	Branch:
+ *		The branch the stash has been created on.
+ *	Date:
+ *		The creation date of the stash.
+ *	Stat:
+ *		The statistics of the stashed changes (only if asked).
+ */
+type StashInfo struct {
+	Index   int
+	Message string
+	Branch  string
+	Date    time.Time
+	Stat    *DiffStat
+}
+
+/*Stashes returns the stashes of the current git repository, from the newest to the oldest one.
+ *If withStat is true, the statistics of the stashed changes (from the index and the working tree) are computed.
+ *If there is an error processing the request, it returns this one.
+ */
+func (g *GitObject) Stashes(withStat bool) ([]StashInfo, error) {
+	if !g.isAccessible() {
+		return nil, g.accessible
+	}
+	var stashes []StashInfo
+	var ids []*git.Oid
+	err := g.repository.Stashes.Foreach(func(index int, message string, id *git.Oid) error {
+		stash := StashInfo{Index: index, Message: message}
+		if matches := stashBranchRegexp.FindStringSubmatch(message); matches != nil {
+			stash.Branch = matches[1]
+		}
+		stashes = append(stashes, stash)
+		ids = append(ids, id)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range stashes {
+		commit, err := g.repository.LookupCommit(ids[i])
+		if err != nil {
+			return nil, err
+		}
+		stashes[i].Date = commit.Committer().When
+		if !withStat || commit.ParentCount() == 0 {
+			continue
+		}
+		if stashes[i].Stat, err = g.diffStat(commit.Parent(0), commit); err != nil {
+			return nil, err
+		}
+	}
+	return stashes, nil
+}
+
+/*diffStat returns the statistics of the diff between two commits
+ */
+func (g *GitObject) diffStat(oldCommit, newCommit *git.Commit) (*DiffStat, error) {
+	oldTree, err := oldCommit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	newTree, err := newCommit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	diff, err := g.repository.DiffTreeToTree(oldTree, newTree, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer diff.Free()
+	stats, err := diff.Stats()
+	if err != nil {
+		return nil, err
+	}
+	defer stats.Free()
+	return &DiffStat{
+		FilesChanged: stats.FilesChanged(),
+		Insertions:   stats.Insertions(),
+		Deletions:    stats.Deletions(),
+	}, nil
+}
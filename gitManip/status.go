@@ -0,0 +1,447 @@
+package gitManip
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	git "gopkg.in/libgit2/git2go.v27"
+)
+
+/*ChangeKind represents the kind of change of a file
+ */
+type ChangeKind int
+
+const (
+	// ChangeAdded means the file has been added
+	ChangeAdded ChangeKind = iota
+	// ChangeDeleted means the file has been deleted
+	ChangeDeleted
+	// ChangeModified means the content of the file has been modified
+	ChangeModified
+	// ChangeRenamed means the file has been renamed
+	ChangeRenamed
+	// ChangeTypeChanged means the type of the file has been changed (like a file replaced by a symbolic link)
+	ChangeTypeChanged
+	// ChangeUntracked means the file is not tracked by git
+	ChangeUntracked
+	// ChangeConflicted means the file has unresolved conflicts
+	ChangeConflicted
+)
+
+/*Map to match the ChangeKind enum type with a string
+ */
+var changeKindToString = map[ChangeKind]string{
+	ChangeAdded:       "added",
+	ChangeDeleted:     "deleted",
+	ChangeModified:    "modified",
+	ChangeRenamed:     "renamed",
+	ChangeTypeChanged: "typechange",
+	ChangeUntracked:   "untracked",
+	ChangeConflicted:  "conflicted",
+}
+
+/*String returns a readable description of the change kind
+ */
+func (k ChangeKind) String() string {
+	return changeKindToString[k]
+}
+
+/*ChangeArea represents where a change of a file is
+ */
+type ChangeArea int
+
+const (
+	// AreaStaged means the change is in the index, and will be part of the next commit
+	AreaStaged ChangeArea = iota
+	// AreaUnstaged means the change is only in the working tree
+	AreaUnstaged
+	// AreaUntracked means the file is only in the working tree, and is not ignored
+	AreaUntracked
+	// AreaConflicted means the file has unresolved conflicts, in the index
+	AreaConflicted
+)
+
+/*Map to match the ChangeArea enum type with a string
+ */
+var changeAreaToString = map[ChangeArea]string{
+	AreaStaged:     "staged",
+	AreaUnstaged:   "unstaged",
+	AreaUntracked:  "untracked",
+	AreaConflicted: "conflicted",
+}
+
+/*String returns a readable description of the change area
+ */
+func (a ChangeArea) String() string {
+	return changeAreaToString[a]
+}
+
+/*FileChange represents a change of a file, in a git repository
+ *
+ *The structure is:
+ *	Path:
+ *		The path of the file, relative to the repository.
+ *	OldPath:
+ *		The previous path of the file (the same as Path, if the file has not been renamed).
+ *	Kind:
+ *		The kind of change.
+ *	Area:
+ *		Where the change is (staged, unstaged, untracked or conflicted).
+ *
+ *A file can have both a staged and an unstaged change.
+ */
+type FileChange struct {
+	Path    string
+	OldPath string
+	Kind    ChangeKind
+	Area    ChangeArea
+}
+
+/*RepoStatus contains informations about the state of a git repository
+ *
+ *The structure is:
+ *	Path:
+ *		The path of the repository.
+ *	Branch:
+ *		The name of the current branch (empty if the HEAD is detached, or if the repository has no commit yet).
+ *	Upstream:
+ *		The name of the upstream branch (empty if there is no upstream branch).
+ *	Ahead:
+ *		The number of commits ahead of the upstream branch.
+ *	Behind:
+ *		The number of commits behind the upstream branch.
+ *	State:
+ *		The operation in progress in the repository (like "Merge" or "Rebase", or "None").
+ *	RebaseStep:
+ *		The current step of the rebase in progress (0 if there is no rebase in progress).
+ *	RebaseTotal:
+ *		The number of steps of the rebase in progress (0 if there is no rebase in progress).
+ *	Detached:
+ *		Is the HEAD of the repository detached?
+ *	LastCommit:
+ *		The date of the HEAD commit (zero if the repository has no commit yet).
+ *	Changes:
+ *		The changed files.
+ *	Stashes:
+ *		The number of stashes.
+ *	Err:
+ *		The error that occurred while reading the repository, if any (the other fields may be incomplete).
+ *	state:
+ *		The raw state of the repository, behind the State label.
+ */
+type RepoStatus struct {
+	Path        string
+	Branch      string
+	Upstream    string
+	Ahead       int
+	Behind      int
+	State       string
+	RebaseStep  int
+	RebaseTotal int
+	Detached    bool
+	LastCommit  time.Time
+	Changes     []FileChange
+	Stashes     int
+	Err         error
+	state       git.RepositoryState
+}
+
+/*IsClean returns if the repository has been read, and has no changed file
+ */
+func (s *RepoStatus) IsClean() bool {
+	return s.Err == nil && len(s.Changes) == 0
+}
+
+/*InProgress returns if an operation (like a merge, a rebase, a bisect or a cherry-pick) is in progress in the
+ *repository
+ */
+func (s *RepoStatus) InProgress() bool {
+	return s.State != "" && s.State != repositoryStateToString[git.RepositoryStateNone]
+}
+
+/*NeedsAttention returns if the repository can't be read, if an operation is in progress, or if some files have
+ *unresolved conflicts
+ */
+func (s *RepoStatus) NeedsAttention() bool {
+	return s.Err != nil || s.InProgress() || s.Count(AreaConflicted) > 0
+}
+
+/*Count returns the number of changes in the given area
+ */
+func (s *RepoStatus) Count(area ChangeArea) int {
+	count := 0
+	for _, change := range s.Changes {
+		if change.Area == area {
+			count++
+		}
+	}
+	return count
+}
+
+/*ChangedFiles returns the number of changed files: a file with both staged and unstaged changes is counted once
+ */
+func (s *RepoStatus) ChangedFiles() int {
+	paths := make(map[string]bool)
+	for _, change := range s.Changes {
+		paths[change.Path] = true
+	}
+	return len(paths)
+}
+
+/*Inspect returns the status of the current git repository.
+ *The returned status is never nil: if the repository can't be read, his Err field contains the error.
+ */
+func (g *GitObject) Inspect() *RepoStatus {
+	status := &RepoStatus{Path: g.path}
+	if !g.isAccessible() {
+		status.Err = g.accessible
+		return status
+	}
+	status.Err = g.inspect(status)
+	return status
+}
+
+/*inspect fills the given status with the informations of the current git repository.
+ *If there is an error processing the request, it returns this one.
+ */
+func (g *GitObject) inspect(status *RepoStatus) error {
+	repositoryState := g.repository.State()
+	status.State = repositoryStateToString[repositoryState]
+	status.state = repositoryState
+	switch repositoryState {
+	case git.RepositoryStateRebase, git.RepositoryStateRebaseInteractive, git.RepositoryStateRebaseMerge, git.RepositoryStateApplyMailboxOrRebase:
+		status.RebaseStep, status.RebaseTotal = g.rebaseProgress()
+	}
+	var err error
+	if status.Detached, err = g.repository.IsHeadDetached(); err != nil {
+		return err
+	}
+	// A new repository does not have any HEAD yet
+	headUnborn, err := g.repository.IsHeadUnborn()
+	if err != nil {
+		return err
+	}
+	if !headUnborn {
+		repositoryHead, err := g.repository.Head()
+		if err != nil {
+			return err
+		}
+		headCommit, err := g.repository.LookupCommit(repositoryHead.Target())
+		if err != nil {
+			return err
+		}
+		status.LastCommit = headCommit.Committer().When
+		if !status.Detached {
+			status.Branch = repositoryHead.Shorthand()
+			if upstream, err := repositoryHead.Branch().Upstream(); err == nil {
+				status.Upstream = upstream.Shorthand()
+				if status.Ahead, status.Behind, err = g.repository.AheadBehind(repositoryHead.Target(), upstream.Target()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if status.Changes, err = g.changes(); err != nil {
+		return err
+	}
+	// The stashes are only informative: they can't make the status fail
+	if stashes, err := g.Stashes(false); err == nil {
+		status.Stashes = len(stashes)
+	}
+	return nil
+}
+
+/*readNumber returns the number written in the given file
+ */
+func readNumber(path string) (int, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(content)))
+}
+
+/*rebaseProgress returns the current step and the number of steps of the rebase in progress, read from the state
+ *files of git (like git does for his prompt).
+ *If the progress is unknown, it returns 0 and 0.
+ */
+func (g *GitObject) rebaseProgress() (int, int) {
+	for _, files := range [][]string{{"rebase-merge", "msgnum", "end"}, {"rebase-apply", "next", "last"}} {
+		directory := filepath.Join(g.repository.Path(), files[0])
+		step, stepErr := readNumber(filepath.Join(directory, files[1]))
+		total, totalErr := readNumber(filepath.Join(directory, files[2]))
+		if stepErr == nil && totalErr == nil {
+			return step, total
+		}
+	}
+	return 0, 0
+}
+
+/*indexChangeKinds and workdirChangeKinds match the git status flags with a ChangeKind, for the index and for the
+ *working tree.
+ *The order matters: a renamed file can also be modified, but it is reported as renamed.
+ */
+var indexChangeKinds = []struct {
+	flag git.Status
+	kind ChangeKind
+}{
+	{git.StatusIndexNew, ChangeAdded},
+	{git.StatusIndexDeleted, ChangeDeleted},
+	{git.StatusIndexRenamed, ChangeRenamed},
+	{git.StatusIndexTypeChange, ChangeTypeChanged},
+	{git.StatusIndexModified, ChangeModified},
+}
+var workdirChangeKinds = []struct {
+	flag git.Status
+	kind ChangeKind
+}{
+	{git.StatusWtNew, ChangeUntracked},
+	{git.StatusWtDeleted, ChangeDeleted},
+	{git.StatusWtRenamed, ChangeRenamed},
+	{git.StatusWtTypeChange, ChangeTypeChanged},
+	{git.StatusWtModified, ChangeModified},
+}
+
+/*deltaPaths returns the new and the old paths of a delta (the new path is empty if the file has been deleted)
+ */
+func deltaPaths(delta git.DiffDelta) (string, string) {
+	if delta.NewFile.Path == "" {
+		return delta.OldFile.Path, delta.OldFile.Path
+	}
+	if delta.OldFile.Path == "" {
+		return delta.NewFile.Path, delta.NewFile.Path
+	}
+	return delta.NewFile.Path, delta.OldFile.Path
+}
+
+/*changes returns the changed files of the current git repository: the staged changes (between HEAD and the index),
+ *the unstaged and untracked ones (between the index and the working tree), and the conflicted files.
+ */
+func (g *GitObject) changes() ([]FileChange, error) {
+	statusList, err := g.repository.StatusList(&statusOption)
+	if err != nil {
+		return nil, err
+	}
+	defer statusList.Free()
+	entryCount, err := statusList.EntryCount()
+	if err != nil {
+		return nil, err
+	}
+	var changes []FileChange
+	for i := 0; i < entryCount; i++ {
+		entry, err := statusList.ByIndex(i)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Status&git.StatusConflicted != 0 {
+			// A conflicted file may only exist on one side of the conflict
+			path, _ := deltaPaths(entry.IndexToWorkdir)
+			if path == "" {
+				path, _ = deltaPaths(entry.HeadToIndex)
+			}
+			changes = append(changes, FileChange{Path: path, OldPath: path, Kind: ChangeConflicted, Area: AreaConflicted})
+			continue
+		}
+		for _, indexKind := range indexChangeKinds {
+			if entry.Status&indexKind.flag != 0 {
+				path, oldPath := deltaPaths(entry.HeadToIndex)
+				changes = append(changes, FileChange{Path: path, OldPath: oldPath, Kind: indexKind.kind, Area: AreaStaged})
+				break
+			}
+		}
+		for _, workdirKind := range workdirChangeKinds {
+			if entry.Status&workdirKind.flag != 0 {
+				path, oldPath := deltaPaths(entry.IndexToWorkdir)
+				change := FileChange{Path: path, OldPath: oldPath, Kind: workdirKind.kind, Area: AreaUnstaged}
+				if workdirKind.kind == ChangeUntracked {
+					change.Area = AreaUntracked
+				}
+				changes = append(changes, change)
+				break
+			}
+		}
+	}
+	return changes, nil
+}
+
+/*Map to match the ChangeArea enum type with the title of his section, in the text output
+ */
+var changeAreaToTitle = map[ChangeArea]string{
+	AreaStaged:     "Staged changes",
+	AreaUnstaged:   "Unstaged changes",
+	AreaUntracked:  "Untracked files",
+	AreaConflicted: "Conflicted files",
+}
+
+/*Text returns the status as a colored, human readable text
+ */
+func (s *RepoStatus) Text() string {
+	var buffer bytes.Buffer
+	if s.Err != nil {
+		buffer.WriteString(fmt.Sprintf("%s %s\t%s\n", color.RedString("✘"), s.Path, color.RedString("can't get the status: %s", s.Err)))
+		return buffer.String()
+	}
+	// The HEAD is detached during a rebase or a bisect
+	if s.Detached && !s.InProgress() {
+		buffer.WriteString(color.RedString("\t/!\\ The repository's HEAD is detached! /!\\\n"))
+	}
+	if len(s.Changes) == 0 && s.NeedsAttention() {
+		buffer.WriteString(fmt.Sprintf("%s %s\n", color.YellowString("⚠"), s.Path))
+	} else if len(s.Changes) > 0 {
+		buffer.WriteString(fmt.Sprintf("%s %9s\t[%d modification(s)]\n", color.RedString("✘"), s.Path, s.ChangedFiles()))
+		for _, area := range []ChangeArea{AreaStaged, AreaUnstaged, AreaUntracked, AreaConflicted} {
+			count := s.Count(area)
+			if count == 0 {
+				continue
+			}
+			buffer.WriteString(fmt.Sprintf("\t%s (%d):\n", changeAreaToTitle[area], count))
+			for _, change := range s.Changes {
+				if change.Area != area {
+					continue
+				}
+				path := color.MagentaString(change.Path)
+				switch change.Kind {
+				case ChangeAdded:
+					buffer.WriteString(fmt.Sprintf("\t===> %s has been added!\n", path))
+				case ChangeDeleted:
+					buffer.WriteString(fmt.Sprintf("\t===> %s has been deleted!\n", path))
+				case ChangeModified:
+					buffer.WriteString(fmt.Sprintf("\t===> %s has been modified!\n", path))
+				case ChangeRenamed:
+					buffer.WriteString(fmt.Sprintf("\t===> %s has been renamed to %s!\n", color.MagentaString(change.OldPath), path))
+				case ChangeUntracked:
+					buffer.WriteString(fmt.Sprintf("\t===> %s is untracked - please to add it or update the gitignore file!\n", path))
+				case ChangeTypeChanged:
+					buffer.WriteString(fmt.Sprintf("\t===> the type of %s has been changed!\n", path))
+				case ChangeConflicted:
+					buffer.WriteString(fmt.Sprintf("\t===> %s has unresolved conflicts!\n", color.RedString(change.Path)))
+				}
+			}
+		}
+	} else {
+		buffer.WriteString(fmt.Sprintf("%s %s\n", color.GreenString("✔"), s.Path))
+	}
+	if s.InProgress() {
+		operation := fmt.Sprintf("%s in progress", s.State)
+		if s.RebaseTotal > 0 {
+			operation += fmt.Sprintf(" (step %d/%d)", s.RebaseStep, s.RebaseTotal)
+		}
+		buffer.WriteString(fmt.Sprintf("\t%s %s - Finish or abort it before going further\n", color.YellowString("⚠"), color.YellowString(operation)))
+	}
+	if s.Ahead != 0 {
+		buffer.WriteString(fmt.Sprintf("\t%s %d commits AHEAD - Soon, you will need to push your modifications\n", color.RedString("⟳"), s.Ahead))
+	}
+	if s.Behind != 0 {
+		buffer.WriteString(fmt.Sprintf("\t%s %d commits BEHIND - Soon, you will need to pull the modifications from the remote branch\n", color.RedString("⟲"), s.Behind))
+	}
+	if s.Stashes > 0 {
+		buffer.WriteString(fmt.Sprintf("\t%s %d stash(es) - Do not forget your stashed work\n", color.YellowString("⚑"), s.Stashes))
+	}
+	return buffer.String()
+}
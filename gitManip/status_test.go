@@ -0,0 +1,121 @@
+package gitManip
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	local := newRepositoryFixture(t)
+	defer os.RemoveAll(local)
+	status := New(local).Inspect()
+	if status.Err != nil {
+		t.Fatalf("The inspection failed: %s", status.Err)
+	}
+	if !status.IsClean() || status.Branch != "master" || status.Upstream != "" || status.Detached {
+		t.Errorf("The status of a fresh repository is not correct, got %+v.", status)
+	}
+
+	commitFile(t, local, "main.go", "package main\n")
+	ioutil.WriteFile(filepath.Join(local, "main.go"), []byte("package goyave\n"), 0644)
+	ioutil.WriteFile(filepath.Join(local, "untracked.go"), []byte("package main\n"), 0644)
+	status = New(local).Inspect()
+	if status.Err != nil {
+		t.Fatalf("The inspection failed: %s", status.Err)
+	}
+	changes := make(map[string]FileChange)
+	for _, change := range status.Changes {
+		changes[change.Path] = change
+	}
+	if change := changes["main.go"]; change.Kind != ChangeModified || change.Area != AreaUnstaged {
+		t.Errorf("main.go should be modified in the working tree, got %+v.", change)
+	}
+	if change := changes["untracked.go"]; change.Kind != ChangeUntracked || change.Area != AreaUntracked {
+		t.Errorf("untracked.go should be untracked, got %+v.", change)
+	}
+
+	if status.Count(AreaUnstaged) != 1 || status.Count(AreaUntracked) != 1 || status.Count(AreaStaged) != 0 {
+		t.Errorf("The counts of changes are not correct, got %+v.", status.Changes)
+	}
+
+	runGit(t, local, "checkout", "--detach")
+	if status = New(local).Inspect(); !status.Detached || status.Branch != "" {
+		t.Errorf("The HEAD should be detached, got %+v.", status)
+	}
+}
+
+func TestInspectUpstream(t *testing.T) {
+	root, local, _ := newRemoteFixture(t)
+	defer os.RemoveAll(root)
+	status := New(local).Inspect()
+	if status.Err != nil {
+		t.Fatalf("The inspection failed: %s", status.Err)
+	}
+	if !status.IsClean() || status.Upstream == "" || status.Ahead != 0 || status.Behind != 0 {
+		t.Errorf("The status of a fresh clone is not correct, got %+v.", status)
+	}
+	commitFile(t, local, "main.go", "package main\n")
+	if status = New(local).Inspect(); status.Ahead != 1 || status.Behind != 0 {
+		t.Errorf("Expected 1 commit ahead, got %d ahead and %d behind.", status.Ahead, status.Behind)
+	}
+}
+
+func TestInspectStagedChanges(t *testing.T) {
+	local := newRepositoryFixture(t)
+	defer os.RemoveAll(local)
+	// Everything is added to the index, but nothing is committed
+	ioutil.WriteFile(filepath.Join(local, "README"), []byte("second"), 0644)
+	ioutil.WriteFile(filepath.Join(local, "main.go"), []byte("package main\n"), 0644)
+	runGit(t, local, "add", "README", "main.go")
+	status := New(local).Inspect()
+	if status.Err != nil {
+		t.Fatalf("The inspection failed: %s", status.Err)
+	}
+	if status.IsClean() || status.Count(AreaStaged) != 2 || status.Count(AreaUnstaged) != 0 {
+		t.Errorf("Expected 2 staged changes, got %+v.", status.Changes)
+	}
+	// A file with both staged and unstaged changes is only one changed file
+	ioutil.WriteFile(filepath.Join(local, "README"), []byte("third"), 0644)
+	status = New(local).Inspect()
+	if status.Count(AreaStaged) != 2 || status.Count(AreaUnstaged) != 1 || status.ChangedFiles() != 2 {
+		t.Errorf("The number of changed files is not correct, got %d instead of 2 (%+v).", status.ChangedFiles(), status.Changes)
+	}
+	dirty, err := New(local).IsDirty()
+	if err != nil || !dirty {
+		t.Errorf("A repository with staged changes should be dirty, got %t (%v).", dirty, err)
+	}
+}
+
+func TestInspectMissingRepository(t *testing.T) {
+	status := New(filepath.Join(os.TempDir(), "goyave-missing-repository")).Inspect()
+	if status.Err == nil {
+		t.Error("Inspecting a missing repository should fail.")
+	}
+}
+
+func TestInspectMergeInProgress(t *testing.T) {
+	local := newRepositoryFixture(t)
+	defer os.RemoveAll(local)
+	runGit(t, local, "checkout", "-b", "feature")
+	commitFile(t, local, "README", "feature")
+	runGit(t, local, "checkout", "-")
+	commitFile(t, local, "README", "conflict")
+	// The merge fails, because of the conflict
+	output, err := gitCommand(local, "merge", "feature").CombinedOutput()
+	if err == nil || !strings.Contains(string(output), "CONFLICT") {
+		t.Fatalf("The merge should fail because of a conflict, got %v:\n%s", err, output)
+	}
+	status := New(local).Inspect()
+	if status.Err != nil {
+		t.Fatalf("The inspection failed: %s", status.Err)
+	}
+	if !status.InProgress() || !status.NeedsAttention() || status.State != "Merge" {
+		t.Errorf("A merge should be in progress, got %+v.", status)
+	}
+	if status.Count(AreaConflicted) != 1 || status.Changes[0].Path != "README" {
+		t.Errorf("README should be conflicted, got %+v.", status.Changes)
+	}
+}
@@ -3,17 +3,30 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
+	"os/exec"
 	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"text/tabwriter"
+	"text/template"
+	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/fatih/color"
 	"github.com/k0pernicus/goyave/configurationFile"
 	"github.com/k0pernicus/goyave/consts"
 	"github.com/k0pernicus/goyave/gitManip"
+	"github.com/k0pernicus/goyave/state"
 	"github.com/k0pernicus/goyave/traces"
 	"github.com/k0pernicus/goyave/utils"
 	"github.com/k0pernicus/goyave/walk"
@@ -22,14 +35,68 @@ import (
 
 var configurationFileStructure configurationFile.ConfigurationFile
 var configurationFilePath string
+var statePath string
 var userHomeDir string
 
-/*initialize get the configuration file existing in the system (or create it), and return
- *a pointer to his content.
+// exitCode is the exit status of the program, set by commands that can partially fail
+var exitCode int
+
+// quiet disables the traces, when the output of goyave is parsed by a shell (like for completion requests)
+var quiet bool
+
+// tracesOutput is where the informations and the warnings are written
+var tracesOutput io.Writer = os.Stdout
+
+// parsedOutputAnnotation annotates the commands whose output is parsed by scripts: their traces are written to the
+// standard error
+const parsedOutputAnnotation = "goyave:parsed-output"
+
+/*shellInitScripts contains, for each supported shell, the template of the jump function
  */
-func initialize(configurationFileStructure *configurationFile.ConfigurationFile) {
+var shellInitScripts = map[string]string{
+	"bash": `# goyave shell integration - add 'eval "$(goyave shell-init {{.Shell}})"' to your shell configuration file
+{{.Name}}() {
+    if [ $# -ne 1 ]; then
+        echo "usage: {{.Name}} <repository>" >&2
+        return 2
+    fi
+    # goyave path uses a fuzzy matching, and asks which repository to use if the pattern is ambiguous
+    local target
+    target="$(command goyave path -- "$1")" || return 1
+    if [ ! -d "$target" ]; then
+        echo "{{.Name}}: $target does not exist" >&2
+        return 1
+    fi
+    cd -- "$target"
+}
+`,
+	"fish": `# goyave shell integration - add 'goyave shell-init fish | source' to your fish configuration file
+function {{.Name}} --description 'Jump to a goyave repository'
+    if test (count $argv) -ne 1
+        echo "usage: {{.Name}} <repository>" >&2
+        return 2
+    end
+    # goyave path uses a fuzzy matching, and asks which repository to use if the pattern is ambiguous
+    set -l target (command goyave path -- $argv[1])
+    or return 1
+    if not test -d "$target"
+        echo "{{.Name}}: $target does not exist" >&2
+        return 1
+    end
+    cd $target
+end
+`,
+}
+
+/*setEnvironment initializes the traces, and sets the user home directory and the configuration file path
+ */
+func setEnvironment() {
 	// Initialize all different traces structures
-	traces.InitTraces(os.Stdout, os.Stderr, os.Stdout, os.Stdout)
+	if quiet {
+		traces.InitTraces(ioutil.Discard, ioutil.Discard, ioutil.Discard, ioutil.Discard)
+	} else {
+		traces.InitTraces(tracesOutput, os.Stderr, tracesOutput, tracesOutput)
+	}
 	// Get the user home directory
 	userHomeDir = utils.GetUserHomeDir()
 	if len(userHomeDir) == 0 {
@@ -37,6 +104,40 @@ func initialize(configurationFileStructure *configurationFile.ConfigurationFile)
 	}
 	// Set the configuration path file
 	configurationFilePath = path.Join(userHomeDir, consts.ConfigurationFileName)
+	// The local state is stored next to the configuration file
+	statePath = path.Join(path.Dir(configurationFilePath), consts.StateFileName)
+}
+
+/*loadState returns the local state of goyave.
+ *If the state can't be read, a warning is printed and an empty state is returned.
+ */
+func loadState() *state.State {
+	localState, err := state.Load(statePath)
+	if err != nil {
+		traces.WarningTracer.Printf("can't read the state file %s: %s\n", statePath, err)
+		localState = state.New()
+	}
+	return localState
+}
+
+/*recordUse records a use of the repositories located at the given paths, in the local state
+ */
+func recordUse(paths ...string) {
+	localState := loadState()
+	now := time.Now()
+	for _, repositoryPath := range paths {
+		localState.Record(repositoryPath, now)
+	}
+	if err := localState.Save(statePath); err != nil {
+		traces.WarningTracer.Printf("can't save the state file %s: %s\n", statePath, err)
+	}
+}
+
+/*initialize get the configuration file existing in the system (or create it), and return
+ *a pointer to his content.
+ */
+func initialize(configurationFileStructure *configurationFile.ConfigurationFile) {
+	setEnvironment()
 	filePointer, err := os.OpenFile(configurationFilePath, os.O_RDWR|os.O_CREATE, 0755)
 	if err != nil {
 		log.Fatalf("can't open the file %s, due to error '%s'\n", configurationFilePath, err)
@@ -63,6 +164,483 @@ func kill() {
 	}
 }
 
+/*crawl crawls the given root directories in order to find git repositories, and adds each new one using the
+ *default target visibility.
+ */
+func crawl(roots []string) {
+	var wg sync.WaitGroup
+	for _, root := range roots {
+		if _, err := os.Stat(root); err != nil {
+			traces.WarningTracer.Printf("can't crawl %s: %s\n", root, err)
+			continue
+		}
+		// Get all git paths, and display them
+		gitPaths, err := walk.RetrieveGitRepositories(root)
+		if err != nil {
+			log.Fatalf("there was an error retrieving your git repositories: '%s'\n", err)
+		}
+		// For each git repository, check if it exists, and if not add it to the default target visibility
+		for _, gitPath := range gitPaths {
+			wg.Add(1)
+			go func(gitPath string) {
+				defer wg.Done()
+				if utils.IsGitRepository(gitPath) {
+					configurationFileStructure.AddRepository(gitPath, configurationFileStructure.Local.DefaultTarget)
+				}
+			}(gitPath)
+		}
+	}
+	wg.Wait()
+}
+
+/*addTagFlags adds the flags to select repositories using their tags, to each given command
+ */
+func addTagFlags(commands ...*cobra.Command) {
+	for _, cmd := range commands {
+		cmd.Flags().StringArray("tag", nil, "select only repositories with this tag (use commas to accept one of many tags)")
+		cmd.Flags().StringArray("not-tag", nil, "ignore repositories with this tag (use commas to ignore many tags)")
+	}
+}
+
+/*Map to match the color names usable in templates with their attribute
+ */
+var templateColors = map[string]color.Attribute{
+	"black":     color.FgBlack,
+	"red":       color.FgRed,
+	"green":     color.FgGreen,
+	"yellow":    color.FgYellow,
+	"blue":      color.FgBlue,
+	"magenta":   color.FgMagenta,
+	"cyan":      color.FgCyan,
+	"white":     color.FgWhite,
+	"bold":      color.Bold,
+	"faint":     color.Faint,
+	"italic":    color.Italic,
+	"underline": color.Underline,
+}
+
+/*templateFuncs contains the helper functions available in the templates given with --template or --template-file:
+ *	color "bold,red" value:
+ *		Colors the value (the colors are disabled if the output is not a terminal).
+ *	pad 20 value, padLeft 20 value:
+ *		Pads the value with spaces, on the right or on the left (pad the value before coloring it).
+ *	relativeTime date:
+ *		Formats the date relatively to now (like "3 days ago").
+ *	join ", " list:
+ *		Joins the strings of the list.
+ */
+var templateFuncs = template.FuncMap{
+	"color": func(names string, value interface{}) (string, error) {
+		var attributes []color.Attribute
+		for _, name := range strings.Split(names, ",") {
+			attribute, ok := templateColors[strings.TrimSpace(name)]
+			if !ok {
+				return "", fmt.Errorf("unknown color %s", name)
+			}
+			attributes = append(attributes, attribute)
+		}
+		return color.New(attributes...).Sprint(value), nil
+	},
+	"pad": func(width int, value interface{}) string {
+		return fmt.Sprintf("%-*v", width, value)
+	},
+	"padLeft": func(width int, value interface{}) string {
+		return fmt.Sprintf("%*v", width, value)
+	},
+	"relativeTime": func(value interface{}) (string, error) {
+		var date time.Time
+		switch v := value.(type) {
+		case time.Time:
+			date = v
+		case *time.Time:
+			if v != nil {
+				date = *v
+			}
+		default:
+			return "", fmt.Errorf("relativeTime needs a date, got %T", value)
+		}
+		if date.IsZero() {
+			return "never", nil
+		}
+		return utils.RelativeTime(date), nil
+	},
+	"join": func(separator string, values []string) string {
+		return strings.Join(values, separator)
+	},
+}
+
+/*addTemplateFlags adds the flags to format the output using a Go template, to each given command
+ */
+func addTemplateFlags(commands ...*cobra.Command) {
+	for _, cmd := range commands {
+		cmd.Flags().String("template", "", "Go template used to print each repository (like '{{.Name}}')")
+		cmd.Flags().String("template-file", "", "file containing the Go template used to print each repository")
+	}
+}
+
+/*readTemplate returns the template given with the --template or --template-file flag of the command, with the
+ *helper functions of templateFuncs.
+ *If no template has been given, it returns nil.
+ */
+func readTemplate(cmd *cobra.Command) (*template.Template, error) {
+	text, _ := cmd.Flags().GetString("template")
+	templateFile, _ := cmd.Flags().GetString("template-file")
+	if text != "" && templateFile != "" {
+		return nil, fmt.Errorf("the --template and --template-file flags can't be used together")
+	}
+	if templateFile != "" {
+		content, err := ioutil.ReadFile(templateFile)
+		if err != nil {
+			return nil, err
+		}
+		// Each repository is printed on his own line: the final newline of the file is not needed
+		text = strings.TrimSuffix(strings.TrimSuffix(string(content), "\n"), "\r")
+	}
+	if text == "" {
+		return nil, nil
+	}
+	return template.New(cmd.Name()).Funcs(templateFuncs).Parse(text)
+}
+
+/*templateFormat returns the format to use for the command, given his --format flag and his template (see
+ *readTemplate): a template implies the template format, and can't be used with another format.
+ */
+func templateFormat(cmd *cobra.Command, t *template.Template) (string, error) {
+	format, _ := cmd.Flags().GetString("format")
+	if t == nil {
+		return format, nil
+	}
+	if !cmd.Flags().Changed("format") {
+		return "template", nil
+	}
+	if format != "template" {
+		return "", fmt.Errorf("the --template and --template-file flags can't be used with the %s format", format)
+	}
+	return format, nil
+}
+
+/*executeTemplate prints the given item using the template, on his own line
+ */
+func executeTemplate(t *template.Template, item interface{}) error {
+	if err := t.Execute(os.Stdout, item); err != nil {
+		return err
+	}
+	fmt.Println()
+	return nil
+}
+
+/*matchTags returns if the given tags match the --tag and --not-tag flags of the command.
+ *Each --tag flag must be matched (a flag can contain many tags, separated by commas, to match one of them), and
+ *none of the --not-tag tags must be matched.
+ */
+func matchTags(cmd *cobra.Command, tags []string) bool {
+	required, _ := cmd.Flags().GetStringArray("tag")
+	excluded, _ := cmd.Flags().GetStringArray("not-tag")
+	hasTag := func(tag string) bool {
+		return utils.SliceIndex(len(tags), func(i int) bool { return tags[i] == tag }) != -1
+	}
+	for _, alternatives := range required {
+		matched := false
+		for _, tag := range strings.Split(alternatives, ",") {
+			if hasTag(strings.TrimSpace(tag)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for _, tags := range excluded {
+		for _, tag := range strings.Split(tags, ",") {
+			if hasTag(strings.TrimSpace(tag)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+/*selectRepositories returns the visible repositories to work on: the ones given as arguments (names or aliases),
+ *or every visible repository if there is no argument.
+ *The repositories that do not match the tag flags of the command are ignored.
+ *The repositories are sorted by name.
+ */
+func selectRepositories(cmd *cobra.Command, args []string) []configurationFile.GroupPath {
+	var repositories []configurationFile.GroupPath
+	if len(args) == 0 {
+		for name, repoPath := range configurationFileStructure.VisibleRepositories {
+			repositories = append(repositories, configurationFile.GroupPath{Name: name, Path: repoPath})
+		}
+	} else {
+		for _, name := range args {
+			if canonicalName, ok := configurationFileStructure.Resolve(name); ok {
+				name = canonicalName
+			}
+			repoPath, ok := configurationFileStructure.VisibleRepositories[name]
+			if ok {
+				repositories = append(repositories, configurationFile.GroupPath{Name: name, Path: repoPath})
+			} else {
+				traces.WarningTracer.Printf("%s cannot be found in your visible repositories\n", name)
+			}
+		}
+	}
+	var selected []configurationFile.GroupPath
+	for _, repository := range repositories {
+		if matchTags(cmd, configurationFileStructure.GetTags(repository.Name)) {
+			selected = append(selected, repository)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Name < selected[j].Name })
+	return selected
+}
+
+/*filterByGroup returns the repositories that are members of the given group (every repository if the group is
+ *empty).
+ */
+func filterByGroup(repositories []configurationFile.GroupPath, group string) []configurationFile.GroupPath {
+	if group == "" {
+		return repositories
+	}
+	members, ok := configurationFileStructure.Groups[group]
+	if !ok {
+		log.Fatalf("the group %s does not exist\n", group)
+	}
+	var filtered []configurationFile.GroupPath
+	for _, repository := range repositories {
+		if members.Contains(repository.Name) {
+			filtered = append(filtered, repository)
+		}
+	}
+	return filtered
+}
+
+/*filterDirty returns the repositories whose working tree contains changes.
+ */
+func filterDirty(repositories []configurationFile.GroupPath, jobs int) []configurationFile.GroupPath {
+	dirty := make([]bool, len(repositories))
+	utils.ParallelRun(len(repositories), jobs, func(i int) {
+		isDirty, err := gitManip.New(repositories[i].Path).IsDirty()
+		if err != nil {
+			traces.WarningTracer.Printf("[%s] %s\n", repositories[i].Name, err)
+		}
+		dirty[i] = isDirty
+	})
+	var filtered []configurationFile.GroupPath
+	for i, repository := range repositories {
+		if dirty[i] {
+			filtered = append(filtered, repository)
+		}
+	}
+	return filtered
+}
+
+/*prefixWriter is a writer that prefixes each line with a given string, and that writes entire lines only to
+ *not mix the output of concurrent writers.
+ */
+type prefixWriter struct {
+	prefix string
+	output io.Writer
+	locker *sync.Mutex
+	buffer []byte
+}
+
+/*Write writes each complete line with his prefix, and keeps the incomplete line for the next call
+ */
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buffer = append(w.buffer, p...)
+	for {
+		i := bytes.IndexByte(w.buffer, '\n')
+		if i == -1 {
+			return len(p), nil
+		}
+		if err := w.writeLine(w.buffer[:i+1]); err != nil {
+			return len(p), err
+		}
+		w.buffer = w.buffer[i+1:]
+	}
+}
+
+/*Flush writes the remaining incomplete line
+ */
+func (w *prefixWriter) Flush() error {
+	if len(w.buffer) == 0 {
+		return nil
+	}
+	err := w.writeLine(append(w.buffer, '\n'))
+	w.buffer = nil
+	return err
+}
+
+func (w *prefixWriter) writeLine(line []byte) error {
+	w.locker.Lock()
+	defer w.locker.Unlock()
+	_, err := fmt.Fprintf(w.output, "%s%s", w.prefix, line)
+	return err
+}
+
+/*isCompletionRequest returns if the command is a completion request, sent by a shell
+ */
+func isCompletionRequest(cmd *cobra.Command) bool {
+	return cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd
+}
+
+/*completeStrings returns the candidates that start with toComplete, and that are not already in args.
+ */
+func completeStrings(candidates []string, args []string, toComplete string) []string {
+	var completions []string
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		seen[arg] = true
+	}
+	for _, candidate := range candidates {
+		if !seen[candidate] && strings.HasPrefix(candidate, toComplete) {
+			completions = append(completions, candidate)
+			seen[candidate] = true
+		}
+	}
+	sort.Strings(completions)
+	return completions
+}
+
+/*completeRepositories returns a completion function for the names (and aliases) of the repositories of the current
+ *host.
+ *Only the arguments between the positions first (included) and last (excluded, or no limit if negative) are
+ *completed, and only the repositories with the given visibility if target is not empty.
+ */
+func completeRepositories(target string, first, last int) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) < first || (last >= 0 && len(args) >= last) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		hostname := utils.GetHostname()
+		var names []string
+		for _, entry := range configurationFileStructure.Entries() {
+			if entry.Host != hostname || (target != "" && entry.Target != target) {
+				continue
+			}
+			names = append(names, entry.Name)
+			names = append(names, entry.Aliases...)
+		}
+		return completeStrings(names, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+/*completeVisibleRepositories returns a completion function for the names (and aliases) of the visible
+ *repositories, for the commands acting on them.
+ *Only the arguments from the position first (included) are completed.
+ */
+func completeVisibleRepositories(first int) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) < first {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var names []string
+		for name := range configurationFileStructure.VisibleRepositories {
+			names = append(names, name)
+			names = append(names, configurationFileStructure.Repositories[name].Aliases...)
+		}
+		return completeStrings(names, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+/*completeTags returns the tags used by the repositories, starting with toComplete
+ */
+func completeTags(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var tags []string
+	for _, entry := range configurationFileStructure.Entries() {
+		tags = append(tags, entry.Tags...)
+	}
+	return completeStrings(tags, nil, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+/*completeGroups returns the names of the groups, starting with toComplete
+ */
+func completeGroups(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var groups []string
+	for group := range configurationFileStructure.Groups {
+		groups = append(groups, group)
+	}
+	return completeStrings(groups, nil, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+/*completeHosts returns the names of the hosts, starting with toComplete
+ */
+func completeHosts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var hosts []string
+	for _, entry := range configurationFileStructure.Entries() {
+		hosts = append(hosts, entry.Host)
+	}
+	return completeStrings(hosts, nil, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+/*setRepositoriesTarget sets the visibility of each repository matching the given names or patterns.
+ */
+func setRepositoriesTarget(patterns []string, target string) {
+	if len(patterns) == 0 {
+		log.Fatalln("Needs a repository name!")
+	}
+	for _, pattern := range patterns {
+		names, err := configurationFileStructure.MatchRepositories(pattern)
+		if err != nil {
+			traces.WarningTracer.Printf("[%s] %s\n", pattern, err)
+			continue
+		}
+		if len(names) == 0 {
+			traces.WarningTracer.Printf("%s does not match any repository of your current host\n", pattern)
+			continue
+		}
+		for _, name := range names {
+			if err := configurationFileStructure.SetTarget(name, target); err != nil {
+				traces.WarningTracer.Printf("[%s] %s\n", name, err)
+				continue
+			}
+			traces.InfoTracer.Printf("%s is now %s\n", name, target)
+		}
+	}
+}
+
+/*printEntries prints the given repository entries, using the given format (table, json, plain or template).
+ */
+func printEntries(entries []configurationFile.RepositoryEntry, format string, t *template.Template) error {
+	switch format {
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tTARGET\tHOST\tPATH")
+		for _, entry := range entries {
+			entryPath := entry.Path
+			if entry.Missing {
+				entryPath += " (missing)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.Name, entry.Target, entry.Host, entryPath)
+		}
+		return w.Flush()
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if entries == nil {
+			entries = []configurationFile.RepositoryEntry{}
+		}
+		return encoder.Encode(entries)
+	case "plain":
+		for _, entry := range entries {
+			fmt.Printf("%s\t%s\n", entry.Name, entry.Path)
+		}
+		return nil
+	case "template":
+		if t == nil {
+			return fmt.Errorf("the template format needs the --template or --template-file flag")
+		}
+		for _, entry := range entries {
+			if err := executeTemplate(t, entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown format %s (available formats are table, json, plain and template)", format)
+}
+
 func main() {
 
 	/*rootCmd defines the global app, and some actions to run before and after the command running
@@ -72,14 +650,61 @@ func main() {
 		Short: "Goyave is a tool to take a look at your local git repositories",
 		// Initialize the structure
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			// Completion requests are parsed by the shell: nothing else must be printed
+			quiet = isCompletionRequest(cmd)
+			if _, ok := cmd.Annotations[parsedOutputAnnotation]; ok {
+				tracesOutput = os.Stderr
+			}
 			initialize(&configurationFileStructure)
 		},
 		// Save the current configuration file structure, in the configuration file
 		PersistentPostRun: func(cmd *cobra.Command, args []string) {
-			kill()
+			if !isCompletionRequest(cmd) {
+				kill()
+			}
 		},
 	}
 
+	/*aliasCmd is a subcommand to manage the aliases of git repositories
+	 */
+	var aliasCmd = &cobra.Command{
+		Use:     "alias",
+		Example: "goyave alias add myRepositoryName myAlias\ngoyave alias rm myRepositoryName myAlias",
+		Short:   "Manage the aliases of repositories",
+		Long:    "An alias is another name of a repository, that can be used by every command (like path or state).",
+	}
+
+	/*aliasAddCmd is a subcommand to add aliases to a git repository
+	 */
+	var aliasAddCmd = &cobra.Command{
+		Use:   "add repository alias [aliases]",
+		Short: "Add aliases to a repository",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, alias := range args[1:] {
+				if err := configurationFileStructure.AddAlias(args[0], alias); err != nil {
+					traces.WarningTracer.Printf("[%s] %s\n", alias, err)
+				}
+			}
+		},
+	}
+
+	/*aliasRmCmd is a subcommand to remove aliases of a git repository
+	 */
+	var aliasRmCmd = &cobra.Command{
+		Use:   "rm repository alias [aliases]",
+		Short: "Remove aliases of a repository",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, alias := range args[1:] {
+				if err := configurationFileStructure.RemoveAlias(args[0], alias); err != nil {
+					traces.WarningTracer.Printf("[%s] %s\n", alias, err)
+				}
+			}
+		},
+	}
+	aliasCmd.AddCommand(aliasAddCmd, aliasRmCmd)
+
 	/*addCmd is a subcommand to add the current working directory as a VISIBLE one
 	 */
 	var addCmd = &cobra.Command{
@@ -101,31 +726,504 @@ func main() {
 		},
 	}
 
+	/*branchesCmd is a subcommand to list the local branches of visible git repositories
+	 */
+	var branchesCmd = &cobra.Command{
+		Use:     "branches",
+		Example: "goyave branches\ngoyave branches --stale 30d\ngoyave branches --merged --no-upstream myRepositoryName",
+		Short:   "List the local branches of each local visible git repository",
+		Long:    "List the local branches of visible git repositories, with their upstream branch, the number of commits ahead and behind, the date of their last commit, and if they are merged into the default branch (origin/HEAD, master or main).\nThe filters --stale, --merged and --no-upstream can be combined.",
+		Run: func(cmd *cobra.Command, args []string) {
+			staleFlag, _ := cmd.Flags().GetString("stale")
+			onlyMerged, _ := cmd.Flags().GetBool("merged")
+			onlyNoUpstream, _ := cmd.Flags().GetBool("no-upstream")
+			jobs, _ := cmd.Flags().GetInt("jobs")
+			var staleBefore time.Time
+			if staleFlag != "" {
+				duration, err := utils.ParseDuration(staleFlag)
+				if err != nil {
+					log.Fatalf("bad --stale value %s: %s\n", staleFlag, err)
+				}
+				staleBefore = time.Now().Add(-duration)
+			}
+			repositories := selectRepositories(cmd, args)
+			results := make([][]gitManip.BranchInfo, len(repositories))
+			utils.ParallelRun(len(repositories), jobs, func(i int) {
+				branches, err := gitManip.New(repositories[i].Path).Branches()
+				if err != nil {
+					traces.ErrorTracer.Printf("[%s] %s\n", repositories[i].Name, err)
+				}
+				results[i] = branches
+			})
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "REPOSITORY\tBRANCH\tUPSTREAM\tAHEAD\tBEHIND\tLAST COMMIT\tMERGED")
+			for i, repository := range repositories {
+				for _, branch := range results[i] {
+					if staleFlag != "" && !branch.LastCommit.Before(staleBefore) {
+						continue
+					}
+					if onlyMerged && !branch.Merged {
+						continue
+					}
+					if onlyNoUpstream && branch.Upstream != "" {
+						continue
+					}
+					name := branch.Name
+					if branch.IsHead {
+						name = "* " + name
+					}
+					upstream, ahead, behind := "-", "-", "-"
+					if branch.Upstream != "" {
+						upstream, ahead, behind = branch.Upstream, strconv.Itoa(branch.Ahead), strconv.Itoa(branch.Behind)
+					}
+					merged := "no"
+					if branch.Merged {
+						merged = "yes"
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", repository.Name, name, upstream, ahead, behind, branch.LastCommit.Local().Format("2006-01-02"), merged)
+				}
+			}
+			w.Flush()
+		},
+	}
+	branchesCmd.Flags().String("stale", "", "list only branches without commit since this duration (like 30d)")
+	branchesCmd.Flags().Bool("merged", false, "list only branches merged into the default branch")
+	branchesCmd.Flags().Bool("no-upstream", false, "list only branches without upstream branch")
+	branchesCmd.Flags().IntP("jobs", "j", 4, "number of repositories to read at the same time")
+
+	/*completionCmd is a subcommand to generate the completion script of a shell
+	 */
+	var completionCmd = &cobra.Command{
+		Use:       "completion bash|zsh|fish",
+		Example:   "source <(goyave completion bash)\ngoyave completion zsh > \"${fpath[1]}/_goyave\"\ngoyave completion fish > ~/.config/fish/completions/goyave.fish",
+		Short:     "Generate the completion script of a shell",
+		Long:      "Generate the completion script of bash, zsh or fish.\nRepository names (and aliases), groups, hosts and tags are completed using your configuration file.",
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: []string{"bash", "zsh", "fish"},
+		// The configuration file is not needed to generate the script
+		PersistentPreRun:  func(cmd *cobra.Command, args []string) {},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {},
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			switch args[0] {
+			case "bash":
+				err = cmd.Root().GenBashCompletionV2(os.Stdout, true)
+			case "zsh":
+				err = cmd.Root().GenZshCompletion(os.Stdout)
+			case "fish":
+				err = cmd.Root().GenFishCompletion(os.Stdout, true)
+			}
+			if err != nil {
+				log.Fatalln(err)
+			}
+		},
+	}
+
 	/*crawlCmd is a subcommand to crawl your hard drive in order to get and save new git repositories
 	 */
 	var crawlCmd = &cobra.Command{
-		Use:   "crawl",
-		Short: "Crawl the hard drive in order to find git repositories",
+		Use:     "crawl",
+		Example: "goyave crawl\ngoyave crawl ~/work ~/perso",
+		Short:   "Crawl the hard drive in order to find git repositories",
+		Long:    "Crawl the given directories.\nIf no directory has been given, goyave crawls the roots of your configuration file (or your home directory, if there is no root).",
 		Run: func(cmd *cobra.Command, args []string) {
-			var wg sync.WaitGroup
-			// Get all git paths, and display them
-			gitPaths, err := walk.RetrieveGitRepositories(userHomeDir)
+			roots := args
+			if len(roots) == 0 {
+				roots = configurationFileStructure.Local.CrawlRoots
+			}
+			if len(roots) == 0 {
+				roots = []string{userHomeDir}
+			}
+			crawl(roots)
+		},
+	}
+
+	/*doctorCmd is a subcommand to check the configuration file and the environment
+	 */
+	var doctorCmd = &cobra.Command{
+		Use:     "doctor",
+		Example: "goyave doctor\ngoyave doctor --fix",
+		Short:   "Check the configuration file and the environment",
+		Long:    "Look for dangling group members, empty or duplicate paths, missing remote URLs, unreadable repositories and libgit2 version problems.\nUse --fix to fix the problems that can be safely fixed.",
+		Run: func(cmd *cobra.Command, args []string) {
+			fix, _ := cmd.Flags().GetBool("fix")
+			// Problems that can't be fixed by goyave
+			unfixable := 0
+			major, minor, revision := gitManip.LibGit2Version()
+			if major != consts.LibGit2Major || minor != consts.LibGit2Minor {
+				fmt.Printf("%s libgit2 %d.%d.%d is used, but goyave supports only libgit2 v%d.%d\n", color.RedString("✘"), major, minor, revision, consts.LibGit2Major, consts.LibGit2Minor)
+				unfixable++
+			}
+			hostname := utils.GetHostname()
+			for _, entry := range configurationFileStructure.Entries() {
+				if entry.Host != hostname || entry.Path == "" {
+					continue
+				}
+				if err := gitManip.Check(entry.Path); err != nil {
+					fmt.Printf("%s the repository %s can't be read: %s (see the prune command)\n", color.RedString("✘"), entry.Name, err)
+					unfixable++
+				}
+			}
+			fixable := 0
+			problems := configurationFileStructure.Diagnose()
+			for _, problem := range problems {
+				if problem.Fixable() {
+					fmt.Printf("%s %s [fixable]\n", color.YellowString("✘"), problem.Description)
+					fixable++
+				} else {
+					fmt.Printf("%s %s\n", color.RedString("✘"), problem.Description)
+					unfixable++
+				}
+			}
+			if unfixable == 0 && fixable == 0 {
+				fmt.Printf("%s no problem found\n", color.GreenString("✔"))
+				return
+			}
+			if fix && fixable > 0 {
+				fixed := configurationFileStructure.Fix(problems)
+				traces.InfoTracer.Printf("%d problem(s) fixed\n", fixed)
+				fixable -= fixed
+			} else if fixable > 0 {
+				traces.InfoTracer.Printf("%d problem(s) can be fixed using --fix\n", fixable)
+			}
+			if unfixable > 0 || fixable > 0 {
+				exitCode = 1
+			}
+		},
+	}
+	doctorCmd.Flags().Bool("fix", false, "fix the problems that can be safely fixed")
+
+	/*execCmd is a subcommand to run a command in each visible git repository
+	 */
+	var execCmd = &cobra.Command{
+		Use:     "exec -- command [arguments]",
+		Example: "goyave exec -- git log -1\ngoyave exec --dirty -- git status --short\ngoyave exec --group work -j 4 --prefix -- make test",
+		Short:   "Run a command in each local visible git repository",
+		Long:    "Run the given command in visible git repositories, using the repository path as working directory.\nThe output is grouped per repository (or each line is prefixed with the repository name, using --prefix), and a summary of the failures is printed at the end.",
+		Run: func(cmd *cobra.Command, args []string) {
+			group, _ := cmd.Flags().GetString("group")
+			onlyDirty, _ := cmd.Flags().GetBool("dirty")
+			jobs, _ := cmd.Flags().GetInt("jobs")
+			prefixOutput, _ := cmd.Flags().GetBool("prefix")
+			if len(args) == 0 {
+				log.Fatalln("Needs a command to run!")
+			}
+			repositories := filterByGroup(selectRepositories(cmd, nil), group)
+			if onlyDirty {
+				repositories = filterDirty(repositories, jobs)
+			}
+			// Only the repositories explicitly selected are considered as used
+			if cmd.Flags().Changed("group") || cmd.Flags().Changed("tag") || cmd.Flags().Changed("not-tag") || onlyDirty {
+				var paths []string
+				for _, repository := range repositories {
+					paths = append(paths, repository.Path)
+				}
+				recordUse(paths...)
+			}
+			var outputLocker sync.Mutex
+			errs := make([]error, len(repositories))
+			utils.ParallelRun(len(repositories), jobs, func(i int) {
+				repository := repositories[i]
+				command := exec.Command(args[0], args[1:]...)
+				command.Dir = repository.Path
+				if prefixOutput {
+					writer := &prefixWriter{prefix: fmt.Sprintf("%s | ", color.CyanString(repository.Name)), output: os.Stdout, locker: &outputLocker}
+					command.Stdout = writer
+					command.Stderr = writer
+					errs[i] = command.Run()
+					writer.Flush()
+					return
+				}
+				output, err := command.CombinedOutput()
+				errs[i] = err
+				outputLocker.Lock()
+				defer outputLocker.Unlock()
+				fmt.Printf("%s %s (%s)\n", color.CyanString("==>"), repository.Name, repository.Path)
+				os.Stdout.Write(output)
+			})
+			var failures []string
+			for i, repository := range repositories {
+				if errs[i] == nil {
+					continue
+				}
+				if exitError, ok := errs[i].(*exec.ExitError); ok {
+					failures = append(failures, fmt.Sprintf("%s (exit status %d)", repository.Name, exitError.ExitCode()))
+				} else {
+					failures = append(failures, fmt.Sprintf("%s (%s)", repository.Name, errs[i]))
+				}
+			}
+			if len(failures) == 0 {
+				fmt.Printf("%s the command succeeded in %d repositories\n", color.GreenString("✔"), len(repositories))
+				return
+			}
+			exitCode = 1
+			fmt.Printf("%s the command failed in %d of %d repositories:\n", color.RedString("✘"), len(failures), len(repositories))
+			for _, failure := range failures {
+				fmt.Printf("\t%s\n", failure)
+			}
+		},
+	}
+	execCmd.Flags().SetInterspersed(false)
+	execCmd.Flags().String("group", "", "run the command only in repositories of this group")
+	execCmd.Flags().Bool("dirty", false, "run the command only in repositories that contain changes")
+	execCmd.Flags().IntP("jobs", "j", 1, "number of commands to run at the same time")
+	execCmd.Flags().Bool("prefix", false, "prefix each output line with the repository name, instead of grouping the output")
+
+	/*fetchCmd is a subcommand to fetch the remotes of visible git repositories
+	 */
+	var fetchCmd = &cobra.Command{
+		Use:     "fetch",
+		Example: "goyave fetch\ngoyave fetch --origin myRepositoryName\ngoyave fetch -j 8",
+		Short:   "Fetch the remotes of each local visible git repository",
+		Long:    "Fetch all the remotes (or only origin) of visible git repositories, in order to get accurate states.\nIf some repository names have been setted, goyave will only fetch those repositories.",
+		Run: func(cmd *cobra.Command, args []string) {
+			onlyOrigin, _ := cmd.Flags().GetBool("origin")
+			jobs, _ := cmd.Flags().GetInt("jobs")
+			var remoteNames []string
+			if onlyOrigin {
+				remoteNames = []string{"origin"}
+			}
+			repositories := selectRepositories(cmd, args)
+			results := make([]*gitManip.FetchResult, len(repositories))
+			errs := make([]error, len(repositories))
+			utils.ParallelRun(len(repositories), jobs, func(i int) {
+				results[i], errs[i] = gitManip.New(repositories[i].Path).Fetch(remoteNames)
+			})
+			for i, repository := range repositories {
+				if errs[i] != nil {
+					fmt.Printf("%s %s\t%s\n", color.RedString("✘"), repository.Name, errs[i])
+					exitCode = 1
+					continue
+				}
+				fmt.Printf("%s %s\t[%d updated reference(s)]\n", color.GreenString("✔"), repository.Name, len(results[i].UpdatedRefs))
+				for _, ref := range results[i].UpdatedRefs {
+					fmt.Printf("\t%.7s..%.7s %s\n", ref.Old, ref.New, ref.Name)
+				}
+			}
+		},
+	}
+	fetchCmd.Flags().Bool("origin", false, "fetch only the origin remote")
+	fetchCmd.Flags().IntP("jobs", "j", 4, "number of repositories to fetch at the same time")
+
+	/*grepCmd is a subcommand to search a pattern in the files of visible git repositories
+	 */
+	var grepCmd = &cobra.Command{
+		Use:     "grep pattern [repositories]",
+		Example: "goyave grep TODO\ngoyave grep -i 'func main' myRepositoryName\ngoyave grep --head --json GetPath",
+		Short:   "Search a pattern in the tracked files of each local visible git repository",
+		Long:    "Search a regular expression in the tracked files of visible git repositories, from the working tree (default) or from the HEAD commit.\nUntracked files can be searched too (using --untracked), except the ones ignored by the repository.\nEach match is printed as repository:path:line:text.",
+		Run: func(cmd *cobra.Command, args []string) {
+			ignoreCase, _ := cmd.Flags().GetBool("ignore-case")
+			fromHead, _ := cmd.Flags().GetBool("head")
+			withUntracked, _ := cmd.Flags().GetBool("untracked")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			jobs, _ := cmd.Flags().GetInt("jobs")
+			if len(args) == 0 {
+				log.Fatalln("Needs a pattern!")
+			}
+			expression := args[0]
+			if ignoreCase {
+				expression = "(?i)" + expression
+			}
+			pattern, err := regexp.Compile(expression)
 			if err != nil {
-				log.Fatalf("there was an error retrieving your git repositories: '%s'\n", err)
+				log.Fatalf("bad pattern %s: %s\n", args[0], err)
 			}
-			// For each git repository, check if it exists, and if not add it to the default target visibility
-			for _, gitPath := range gitPaths {
-				wg.Add(1)
-				go func(gitPath string) {
-					defer wg.Done()
-					if utils.IsGitRepository(gitPath) {
-						configurationFileStructure.AddRepository(gitPath, configurationFileStructure.Local.DefaultTarget)
+			repositories := selectRepositories(cmd, args[1:])
+			results := make([][]gitManip.GrepMatch, len(repositories))
+			utils.ParallelRun(len(repositories), jobs, func(i int) {
+				matches, err := gitManip.New(repositories[i].Path).Grep(pattern, fromHead, withUntracked)
+				if err != nil {
+					traces.ErrorTracer.Printf("[%s] %s\n", repositories[i].Name, err)
+				}
+				results[i] = matches
+			})
+			type repositoryMatch struct {
+				Repository string `json:"repository"`
+				gitManip.GrepMatch
+			}
+			allMatches := []repositoryMatch{}
+			for i, repository := range repositories {
+				for _, match := range results[i] {
+					allMatches = append(allMatches, repositoryMatch{Repository: repository.Name, GrepMatch: match})
+				}
+			}
+			if len(allMatches) == 0 {
+				exitCode = 1
+			}
+			if jsonOutput {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(allMatches); err != nil {
+					log.Fatalln(err)
+				}
+				return
+			}
+			for _, match := range allMatches {
+				fmt.Printf("%s:%s:%s:%s\n", color.MagentaString(match.Repository), color.CyanString(match.Path), color.GreenString("%d", match.Line), match.Text)
+			}
+		},
+	}
+	grepCmd.Flags().BoolP("ignore-case", "i", false, "ignore case distinctions")
+	grepCmd.Flags().Bool("head", false, "search in the HEAD commit instead of the working tree")
+	grepCmd.Flags().Bool("untracked", false, "search in untracked files too (except ignored ones)")
+	grepCmd.Flags().Bool("json", false, "print the matches as JSON")
+	grepCmd.Flags().IntP("jobs", "j", 4, "number of repositories to search at the same time")
+
+	/*hideCmd is a subcommand to set repositories as HIDDEN ones
+	 */
+	var hideCmd = &cobra.Command{
+		Use:     "hide",
+		Example: "goyave hide myRepositoryName\ngoyave hide 'myRepository*'",
+		Short:   "Set repositories as HIDDEN ones",
+		Long:    "Hidden repositories are still known by goyave, but are ignored by the state command.\nRepository names can be shell patterns.",
+		Run: func(cmd *cobra.Command, args []string) {
+			setRepositoriesTarget(args, consts.HiddenFlag)
+		},
+	}
+
+	/*initCmd is a subcommand to create the configuration file, by asking some questions to the user
+	 */
+	var initCmd = &cobra.Command{
+		Use:     "init",
+		Example: "goyave init\ngoyave init --defaults --crawl\ngoyave init --force",
+		Short:   "Create the configuration file",
+		Long:    "Create the configuration file, by asking the author name, the default target, the group name and the directories to crawl.\nThe group contains the visible repositories of the current host (the hostname is used by default).\nAn existing configuration file is never overwritten, except if the --force flag is set.",
+		// The configuration file should not be read (or created) before running the command
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			setEnvironment()
+		},
+		// The configuration file is saved by the command itself
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {},
+		Run: func(cmd *cobra.Command, args []string) {
+			useDefaults, _ := cmd.Flags().GetBool("defaults")
+			force, _ := cmd.Flags().GetBool("force")
+			runCrawl, _ := cmd.Flags().GetBool("crawl")
+			if fileState, err := os.Stat(configurationFilePath); err == nil && fileState.Size() > 0 && !force {
+				log.Fatalf("the configuration file %s already exists - use --force to overwrite it\n", configurationFilePath)
+			}
+			author := utils.GetUserName()
+			target := consts.VisibleFlag
+			group := utils.GetHostname()
+			roots := []string{userHomeDir}
+			if !useDefaults {
+				author = utils.AskString("Author", author)
+				for {
+					target = strings.ToUpper(utils.AskString(fmt.Sprintf("Default target of new repositories (%s or %s)", consts.VisibleFlag, consts.HiddenFlag), target))
+					if target == consts.VisibleFlag || target == consts.HiddenFlag {
+						break
+					}
+					traces.WarningTracer.Printf("%s is not a valid target\n", target)
+					target = consts.VisibleFlag
+				}
+				group = utils.AskString("Group name", group)
+				roots = nil
+				for _, root := range strings.Split(utils.AskString("Directories to crawl (comma separated)", userHomeDir), ",") {
+					root = strings.TrimSpace(root)
+					if strings.HasPrefix(root, "~") {
+						root = filepath.Join(userHomeDir, root[1:])
 					}
-				}(gitPath)
+					if root != "" {
+						roots = append(roots, root)
+					}
+				}
+				if !runCrawl {
+					runCrawl = utils.AskConfirmation("Crawl those directories now?")
+				}
+			}
+			configurationFileStructure.Author = author
+			configurationFileStructure.Local = configurationFile.LocalInformations{
+				DefaultTarget: target,
+				Group:         group,
+				CrawlRoots:    roots,
+			}
+			configurationFileStructure.Repositories = nil
+			configurationFileStructure.Groups = map[string]configurationFile.Group{
+				group: []string{},
+			}
+			configurationFileStructure.Process()
+			if runCrawl {
+				crawl(roots)
+			}
+			kill()
+			traces.InfoTracer.Printf("The configuration file %s has been created\n", configurationFilePath)
+		},
+	}
+	initCmd.Flags().Bool("defaults", false, "do not ask anything, and use the default values")
+	initCmd.Flags().Bool("force", false, "overwrite the existing configuration file")
+	initCmd.Flags().Bool("crawl", false, "crawl the directories once the configuration file is created")
+
+	/*listCmd is a subcommand to list the repositories known by goyave
+	 */
+	var listCmd = &cobra.Command{
+		Use:         "list",
+		Annotations: map[string]string{parsedOutputAnnotation: "true"},
+		Example:     "goyave list\ngoyave list --hidden\ngoyave list --host '*' --format json\ngoyave list --missing --format plain\ngoyave list --template '{{.Name}} -> {{.URL}}'\ngoyave list --template '{{pad 20 .Name | color \"bold\"}} {{join \",\" .Tags}}'",
+		Short:       "List the repositories stored in the configuration file",
+		Long:        "List the repositories of the current host (or the ones of the hosts matching --host, which can be a shell pattern).\nAvailable formats are table, json, plain (name and path, separated by a tab) and template (using the Go text/template syntax, given with --template or --template-file, on each repository entry - see the README for the fields and the helper functions).",
+		Run: func(cmd *cobra.Command, args []string) {
+			onlyVisible, _ := cmd.Flags().GetBool("visible")
+			onlyHidden, _ := cmd.Flags().GetBool("hidden")
+			all, _ := cmd.Flags().GetBool("all")
+			onlyMissing, _ := cmd.Flags().GetBool("missing")
+			host, _ := cmd.Flags().GetString("host")
+			group, _ := cmd.Flags().GetString("group")
+			t, err := readTemplate(cmd)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			format, err := templateFormat(cmd, t)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			selected := 0
+			for _, flag := range []bool{onlyVisible, onlyHidden, all} {
+				if flag {
+					selected++
+				}
+			}
+			if selected > 1 {
+				log.Fatalln("only one of the --visible, --hidden and --all flags can be used")
+			}
+			if host == "" {
+				host = utils.GetHostname()
+			}
+			var entries []configurationFile.RepositoryEntry
+			for _, entry := range configurationFileStructure.Entries() {
+				if matched, err := filepath.Match(host, entry.Host); err != nil {
+					log.Fatalf("bad host pattern %s: %s\n", host, err)
+				} else if !matched {
+					continue
+				}
+				if onlyVisible && entry.Target != consts.VisibleFlag {
+					continue
+				}
+				if onlyHidden && entry.Target != consts.HiddenFlag {
+					continue
+				}
+				if onlyMissing && !entry.Missing {
+					continue
+				}
+				if group != "" && utils.SliceIndex(len(entry.Groups), func(i int) bool { return entry.Groups[i] == group }) == -1 {
+					continue
+				}
+				if !matchTags(cmd, entry.Tags) {
+					continue
+				}
+				entries = append(entries, entry)
+			}
+			if err := printEntries(entries, format, t); err != nil {
+				log.Fatalln(err)
 			}
-			wg.Wait()
 		},
 	}
+	listCmd.Flags().Bool("visible", false, "list only VISIBLE repositories (can't be used with --hidden or --all)")
+	listCmd.Flags().Bool("hidden", false, "list only HIDDEN repositories (can't be used with --visible or --all)")
+	listCmd.Flags().Bool("all", false, "list VISIBLE and HIDDEN repositories (default, can't be used with --visible or --hidden)")
+	listCmd.Flags().Bool("missing", false, "list only repositories whose path no longer exists")
+	listCmd.Flags().String("host", "", "list repositories of the hosts matching this pattern (default is the current host)")
+	listCmd.Flags().String("group", "", "list only repositories of this group")
+	listCmd.Flags().StringP("format", "f", "table", "output format: table, json, plain or template")
 
 	/*loadCmd permits to load visible repositories from the goyave configuration file
 	 */
@@ -173,67 +1271,616 @@ func main() {
 		},
 	}
 
+	/*logCmd is a subcommand to get the recent commits of visible git repositories, as one timeline
+	 */
+	var logCmd = &cobra.Command{
+		Use:     "log",
+		Example: "goyave log\ngoyave log --since 24h --author me\ngoyave log --since 7d --format markdown myRepositoryName1 myRepositoryName2",
+		Short:   "Get the recent commits of each local visible git repository, as one timeline",
+		Long:    "Get the commits reachable from the HEAD of visible git repositories, sorted from the newest to the oldest one.\nThe --since flag accepts durations (like 24h, 7d or 2w) and dates (like 2006-01-02).\nThe --author flag is searched in the author names and emails, and \"me\" matches the git user of each repository.",
+		Run: func(cmd *cobra.Command, args []string) {
+			sinceFlag, _ := cmd.Flags().GetString("since")
+			author, _ := cmd.Flags().GetString("author")
+			format, _ := cmd.Flags().GetString("format")
+			jobs, _ := cmd.Flags().GetInt("jobs")
+			if format != "text" && format != "markdown" {
+				log.Fatalf("unknown format %s (available formats are text and markdown)\n", format)
+			}
+			since, err := time.ParseInLocation("2006-01-02", sinceFlag, time.Local)
+			if err != nil {
+				duration, err := utils.ParseDuration(sinceFlag)
+				if err != nil {
+					log.Fatalf("bad --since value %s: %s\n", sinceFlag, err)
+				}
+				since = time.Now().Add(-duration)
+			}
+			repositories := selectRepositories(cmd, args)
+			results := make([][]gitManip.CommitInfo, len(repositories))
+			utils.ParallelRun(len(repositories), jobs, func(i int) {
+				commits, err := gitManip.New(repositories[i].Path).Log(since, author)
+				if err != nil {
+					traces.ErrorTracer.Printf("[%s] %s\n", repositories[i].Name, err)
+				}
+				results[i] = commits
+			})
+			type timelineEntry struct {
+				repository string
+				gitManip.CommitInfo
+			}
+			var timeline []timelineEntry
+			for i, repository := range repositories {
+				for _, commit := range results[i] {
+					timeline = append(timeline, timelineEntry{repository: repository.Name, CommitInfo: commit})
+				}
+			}
+			sort.SliceStable(timeline, func(i, j int) bool { return timeline[i].Date.After(timeline[j].Date) })
+			for _, entry := range timeline {
+				date := entry.Date.Local().Format("2006-01-02 15:04")
+				if format == "markdown" {
+					fmt.Printf("- %s **%s**: %s (`%.7s`, %s)\n", date, entry.repository, entry.Summary, entry.ID, entry.Author)
+				} else {
+					fmt.Printf("%s %s %s %s (%s)\n", date, color.MagentaString(entry.repository), color.YellowString("%.7s", entry.ID), entry.Summary, entry.Author)
+				}
+			}
+		},
+	}
+	logCmd.Flags().String("since", "24h", "get the commits written since this duration or date")
+	logCmd.Flags().String("author", "", "get only the commits of this author (\"me\" for the current git user)")
+	logCmd.Flags().StringP("format", "f", "text", "output format: text or markdown")
+	logCmd.Flags().IntP("jobs", "j", 4, "number of repositories to read at the same time")
+
+	/*tagCmd is a subcommand to manage the tags of git repositories
+	 */
+	var tagCmd = &cobra.Command{
+		Use:     "tag",
+		Example: "goyave tag add myRepositoryName work\ngoyave tag rm myRepositoryName work\ngoyave state --tag work --not-tag archived",
+		Short:   "Manage the tags of repositories",
+		Long:    "Tags are free-form labels (like work, oss or infra), used to select repositories with the --tag and --not-tag flags of the commands working on many repositories.",
+	}
+
+	/*tagAddCmd is a subcommand to add tags to a git repository
+	 */
+	var tagAddCmd = &cobra.Command{
+		Use:   "add repository tag [tags]",
+		Short: "Add tags to a repository",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, tag := range args[1:] {
+				if err := configurationFileStructure.AddTag(args[0], tag); err != nil {
+					traces.WarningTracer.Printf("[%s] %s\n", tag, err)
+				}
+			}
+		},
+	}
+
+	/*tagRmCmd is a subcommand to remove tags of a git repository
+	 */
+	var tagRmCmd = &cobra.Command{
+		Use:   "rm repository tag [tags]",
+		Short: "Remove tags of a repository",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, tag := range args[1:] {
+				if err := configurationFileStructure.RemoveTag(args[0], tag); err != nil {
+					traces.WarningTracer.Printf("[%s] %s\n", tag, err)
+				}
+			}
+		},
+	}
+	tagCmd.AddCommand(tagAddCmd, tagRmCmd)
+
 	/*pathCmd is a subcommand to get the path of a given git repository.
-	 *This subcommand is useful to change directory, like `cd $(goyave path mygitrepo)`
+	 *The repository can be given by his name, an alias, or a part of those ones.
 	 */
 	var pathCmd = &cobra.Command{
-		Use:   "path",
-		Short: "Get the path of a given repository, if this one exists",
+		Use:         "path",
+		Annotations: map[string]string{parsedOutputAnnotation: "true"},
+		Example:     "goyave path goyave\ngoyave path goy\ngoyave path gyv --all",
+		Short:       "Get the path of a given repository, if this one exists",
+		Long:        "Get the path of a visible repository, from his name, one of his aliases, a prefix, a substring, or a fuzzy pattern (the characters of the name, in order).\nThe best match is printed: exact matches come before prefixes, substrings and fuzzy matches, then the most frequently and recently used repositories come first.\nIf several repositories match equally, the choice is asked when goyave runs in a terminal, otherwise the candidates are listed and goyave fails.\nIf the --all flag is set, every candidate is printed with his name.",
+		Args:        cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) == 0 {
-				log.Fatalln("Needs a repository name!")
+			all, _ := cmd.Flags().GetBool("all")
+			localState := loadState()
+			now := time.Now()
+			score := func(repositoryPath string) float64 {
+				return localState.Score(repositoryPath, now)
 			}
-			repo := args[0]
-			repoPath, found := configurationFileStructure.GetPath(repo)
-			if !found {
-				log.Fatalf("repository %s not found\n", repo)
+			matches := configurationFileStructure.FindPaths(args[0], score)
+			if len(matches) == 0 {
+				log.Fatalf("repository %s not found\n", args[0])
+			}
+			if all {
+				for _, match := range matches {
+					fmt.Printf("%s\t%s\n", match.Name, match.Path)
+				}
+				return
+			}
+			// Only the best matches are candidates, and the most used one wins
+			var candidates []string
+			for _, match := range matches {
+				if match.Kind != matches[0].Kind {
+					break
+				}
+				candidates = append(candidates, fmt.Sprintf("%s\t%s", match.Name, match.Path))
+			}
+			chosen := 0
+			if len(candidates) > 1 && score(matches[0].Path) == score(matches[1].Path) {
+				if !utils.IsTerminal(os.Stdin) || !utils.IsTerminal(os.Stderr) {
+					fmt.Fprintf(os.Stderr, "%s is ambiguous, candidates are:\n", args[0])
+					for _, candidate := range candidates {
+						fmt.Fprintf(os.Stderr, "    %s\n", candidate)
+					}
+					os.Exit(1)
+				}
+				if chosen = utils.AskChoice(fmt.Sprintf("%s is ambiguous, which repository?", args[0]), candidates); chosen == -1 {
+					log.Fatalln("invalid choice")
+				}
+			}
+			recordUse(matches[chosen].Path)
+			fmt.Println(matches[chosen].Path)
+		},
+	}
+	pathCmd.Flags().Bool("all", false, "print every matching repository, with his name")
+
+	/*pruneCmd is a subcommand to remove the repositories of the current host that no longer exist
+	 */
+	var pruneCmd = &cobra.Command{
+		Use:     "prune",
+		Example: "goyave prune --dry-run\ngoyave prune\ngoyave prune --all-hosts --yes",
+		Short:   "Remove the repositories whose path no longer exists",
+		Long:    "Remove the repositories of the current host whose directory is missing, or is no longer a git repository.\nBy default, only the path of the current host is removed (the repository is entirely removed if no other host knows it).\nIf the --all-hosts flag is set, the repositories are removed for every host.",
+		Run: func(cmd *cobra.Command, args []string) {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			allHosts, _ := cmd.Flags().GetBool("all-hosts")
+			skipConfirmation, _ := cmd.Flags().GetBool("yes")
+			hostname := utils.GetHostname()
+			var names []string
+			for _, entry := range configurationFileStructure.Entries() {
+				if entry.Host != hostname {
+					continue
+				}
+				switch {
+				case entry.Missing:
+					fmt.Printf("%s %s\t%s does not exist\n", color.RedString("✘"), entry.Name, entry.Path)
+				case !utils.IsGitRepository(entry.Path):
+					fmt.Printf("%s %s\t%s is not a git repository\n", color.RedString("✘"), entry.Name, entry.Path)
+				default:
+					continue
+				}
+				names = append(names, entry.Name)
+			}
+			if len(names) == 0 {
+				traces.InfoTracer.Println("Nothing to prune")
+				return
+			}
+			if dryRun {
+				return
+			}
+			question := fmt.Sprintf("Remove those %d repositories from the current host?", len(names))
+			if allHosts {
+				question = fmt.Sprintf("Remove those %d repositories from every host?", len(names))
+			}
+			if !skipConfirmation && !utils.AskConfirmation(question) {
+				traces.InfoTracer.Println("Nothing has been removed")
+				return
+			}
+			for _, name := range names {
+				if err := configurationFileStructure.RemoveRepository(name, allHosts); err != nil {
+					traces.WarningTracer.Printf("[%s] %s\n", name, err)
+					continue
+				}
+				traces.InfoTracer.Printf("%s has been removed\n", name)
+			}
+		},
+	}
+	pruneCmd.Flags().Bool("dry-run", false, "list the repositories to remove, without removing them")
+	pruneCmd.Flags().Bool("all-hosts", false, "remove the repositories for every host")
+	pruneCmd.Flags().BoolP("yes", "y", false, "do not ask for confirmation")
+
+	/*pullCmd is a subcommand to fast-forward clean visible git repositories
+	 */
+	var pullCmd = &cobra.Command{
+		Use:     "pull",
+		Example: "goyave pull\ngoyave pull --ff-only myRepositoryName1 myRepositoryName2",
+		Short:   "Fast-forward each local visible git repository that is behind his upstream branch",
+		Long:    "Fast-forward the current branch of visible git repositories to their upstream branch, using the last fetched state (see the fetch command).\nDirty, diverged, detached and upstream-less repositories are skipped.\nIf some repository names have been setted, goyave will only update those repositories.",
+		Run: func(cmd *cobra.Command, args []string) {
+			jobs, _ := cmd.Flags().GetInt("jobs")
+			repositories := selectRepositories(cmd, args)
+			results := make([]*gitManip.PullResult, len(repositories))
+			errs := make([]error, len(repositories))
+			utils.ParallelRun(len(repositories), jobs, func(i int) {
+				results[i], errs[i] = gitManip.New(repositories[i].Path).FastForward()
+			})
+			for i, repository := range repositories {
+				switch {
+				case errs[i] != nil:
+					fmt.Printf("%s %s\t%s\n", color.RedString("✘"), repository.Name, errs[i])
+					exitCode = 1
+				case results[i].Status == gitManip.PullUpdated:
+					fmt.Printf("%s %s\t%s: %.7s..%.7s [%d commit(s)]\n", color.GreenString("✔"), repository.Name, results[i].Branch, results[i].Old, results[i].New, results[i].Commits)
+				case results[i].Status == gitManip.PullUpToDate:
+					fmt.Printf("%s %s\t%s\n", color.GreenString("✔"), repository.Name, results[i].Status)
+				default:
+					fmt.Printf("%s %s\t%s\n", color.YellowString("-"), repository.Name, results[i].Status)
+				}
+			}
+		},
+	}
+	// Fast-forward is the only mode: the flag is accepted to be explicit, but changes nothing
+	pullCmd.Flags().Bool("ff-only", true, "only fast-forward the branches (always enabled, this is the only supported mode)")
+	pullCmd.Flags().IntP("jobs", "j", 4, "number of repositories to update at the same time")
+
+	/*pushCmd is a subcommand to push visible git repositories that are ahead of their upstream branch
+	 */
+	var pushCmd = &cobra.Command{
+		Use:     "push",
+		Example: "goyave push\ngoyave push --dry-run\ngoyave push myRepositoryName1 myRepositoryName2",
+		Short:   "Push the current branch of each local visible git repository that is ahead of his upstream branch",
+		Long:    "Push the current branch of visible git repositories that are ahead, and not behind, their upstream branch.\nThe push is never forced: diverged repositories are skipped.\nIf some repository names have been setted, goyave will only push those repositories.",
+		Run: func(cmd *cobra.Command, args []string) {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			jobs, _ := cmd.Flags().GetInt("jobs")
+			repositories := selectRepositories(cmd, args)
+			results := make([]*gitManip.PushResult, len(repositories))
+			errs := make([]error, len(repositories))
+			utils.ParallelRun(len(repositories), jobs, func(i int) {
+				results[i], errs[i] = gitManip.New(repositories[i].Path).Push(dryRun)
+			})
+			for i, repository := range repositories {
+				switch {
+				case errs[i] != nil:
+					fmt.Printf("%s %s\t%s\n", color.RedString("✘"), repository.Name, errs[i])
+					exitCode = 1
+				case results[i].Status == gitManip.PushPushed || results[i].Status == gitManip.PushDryRun:
+					fmt.Printf("%s %s\t%s -> %s/%s %s [%d commit(s)]\n", color.GreenString("✔"), repository.Name, results[i].Branch, results[i].Remote, strings.TrimPrefix(results[i].Upstream, "refs/heads/"), results[i].Status, results[i].Commits)
+				case results[i].Status == gitManip.PushUpToDate:
+					fmt.Printf("%s %s\t%s\n", color.GreenString("✔"), repository.Name, results[i].Status)
+				default:
+					fmt.Printf("%s %s\t%s\n", color.YellowString("-"), repository.Name, results[i].Status)
+				}
+			}
+		},
+	}
+	pushCmd.Flags().Bool("dry-run", false, "show what would be pushed, without pushing anything")
+	pushCmd.Flags().IntP("jobs", "j", 4, "number of repositories to push at the same time")
+
+	/*recentCmd is a subcommand to list the most used repositories
+	 */
+	var recentCmd = &cobra.Command{
+		Use:         "recent",
+		Annotations: map[string]string{parsedOutputAnnotation: "true"},
+		Example:     "goyave recent\ngoyave recent -n 3 --format plain",
+		Short:       "List the most frequently and recently used repositories",
+		Long:        "List the visible repositories used through goyave path (and the shell integration) or goyave exec, from the most to the least frequently and recently used.\nThe uses are stored in the state file " + consts.StateFileName + ", next to the configuration file.\nAvailable formats are table and plain (name and path, separated by a tab).",
+		Args:        cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			limit, _ := cmd.Flags().GetInt("limit")
+			format, _ := cmd.Flags().GetString("format")
+			if format != "table" && format != "plain" {
+				log.Fatalf("unknown format %s\n", format)
+			}
+			localState := loadState()
+			now := time.Now()
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			if format == "table" {
+				fmt.Fprintln(w, "NAME\tUSES\tLAST USE\tPATH")
+			}
+			printed := 0
+			for _, repositoryPath := range localState.Recent(now) {
+				if limit > 0 && printed == limit {
+					break
+				}
+				// The repositories that are no longer visible are ignored
+				name, ok := configurationFileStructure.GetRepositoryName(repositoryPath)
+				if visiblePath, visible := configurationFileStructure.GetPath(name); !ok || !visible || visiblePath != repositoryPath {
+					continue
+				}
+				usage := localState.Repositories[repositoryPath]
+				if format == "plain" {
+					fmt.Fprintf(w, "%s\t%s\n", name, repositoryPath)
+				} else {
+					fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", name, usage.Count, utils.RelativeTime(usage.LastAccess), repositoryPath)
+				}
+				printed++
+			}
+			if err := w.Flush(); err != nil {
+				log.Fatalln(err)
+			}
+		},
+	}
+	recentCmd.Flags().IntP("limit", "n", 10, "maximum number of repositories to list (0 to list all)")
+	recentCmd.Flags().String("format", "table", "output format: table or plain")
+
+	/*removeCmd is a subcommand to unregister git repositories from the configuration file
+	 */
+	var removeCmd = &cobra.Command{
+		Use:     "remove",
+		Example: "goyave remove myRepositoryName\ngoyave remove --all-hosts myRepositoryName1 myRepositoryName2\ngoyave remove --path\ngoyave remove --path /home/user/myRepository",
+		Short:   "Remove repositories from the configuration file",
+		Long:    "Forget the given repositories for the current host.\nIf the --all-hosts flag is set, the repositories are removed for every host.\nIf the --path flag is set, the arguments are local paths (the current directory if there is no argument).",
+		Run: func(cmd *cobra.Command, args []string) {
+			allHosts, _ := cmd.Flags().GetBool("all-hosts")
+			byPath, _ := cmd.Flags().GetBool("path")
+			skipConfirmation, _ := cmd.Flags().GetBool("yes")
+			var names []string
+			if byPath {
+				if len(args) == 0 {
+					currentDir, err := os.Getwd()
+					if err != nil {
+						log.Fatalln("There was a problem retrieving the current directory")
+					}
+					args = []string{currentDir}
+				}
+				for _, repoPath := range args {
+					absPath, err := filepath.Abs(repoPath)
+					if err != nil {
+						log.Fatalf("can't get the absolute path of %s: %s\n", repoPath, err)
+					}
+					name, found := configurationFileStructure.GetRepositoryName(absPath)
+					if !found {
+						traces.WarningTracer.Printf("%s is not a registered repository\n", absPath)
+						continue
+					}
+					names = append(names, name)
+				}
 			} else {
-				fmt.Println(repoPath)
+				names = args
+			}
+			if len(names) == 0 {
+				log.Fatalln("Needs a repository name!")
+			}
+			question := fmt.Sprintf("Remove %s from the current host?", strings.Join(names, ", "))
+			if allHosts {
+				question = fmt.Sprintf("Remove %s from every host?", strings.Join(names, ", "))
+			}
+			if !skipConfirmation && !utils.AskConfirmation(question) {
+				traces.InfoTracer.Println("Nothing has been removed")
+				return
+			}
+			for _, name := range names {
+				if err := configurationFileStructure.RemoveRepository(name, allHosts); err != nil {
+					traces.WarningTracer.Printf("[%s] %s\n", name, err)
+					continue
+				}
+				traces.InfoTracer.Printf("%s has been removed\n", name)
 			}
 		},
 	}
+	removeCmd.Flags().Bool("all-hosts", false, "remove the repositories for every host")
+	removeCmd.Flags().Bool("path", false, "use local paths instead of repository names")
+	removeCmd.Flags().BoolP("yes", "y", false, "do not ask for confirmation")
+
+	/*renameCmd is a subcommand to rename a git repository
+	 */
+	var renameCmd = &cobra.Command{
+		Use:     "rename old new",
+		Example: "goyave rename myRepositoryName myNewName\ngoyave rename --keep-alias myRepositoryName myNewName",
+		Short:   "Rename a repository",
+		Long:    "Rename a repository for every host, and update the groups that contain it.\nUse --keep-alias to keep the old name as an alias.",
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			keepAlias, _ := cmd.Flags().GetBool("keep-alias")
+			if err := configurationFileStructure.RenameRepository(args[0], args[1], keepAlias); err != nil {
+				log.Fatalln(err)
+			}
+			traces.InfoTracer.Printf("%s has been renamed to %s\n", args[0], args[1])
+		},
+	}
+	renameCmd.Flags().Bool("keep-alias", false, "keep the old name as an alias")
+
+	/*shellInitCmd is a subcommand to print the shell integration of goyave
+	 */
+	var shellInitCmd = &cobra.Command{
+		Use:       "shell-init bash|zsh|fish",
+		Example:   "eval \"$(goyave shell-init bash)\"\neval \"$(goyave shell-init zsh --name jump)\"\ngoyave shell-init fish | source",
+		Short:     "Print the shell integration of goyave",
+		Long:      "Print a shell function (gcd by default) that jumps to a visible repository.\nThe function uses the fuzzy matching of goyave path, and returns a non-zero status if no repository matches.",
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: []string{"bash", "zsh", "fish"},
+		// The configuration file is not needed to print the integration
+		PersistentPreRun:  func(cmd *cobra.Command, args []string) {},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {},
+		Run: func(cmd *cobra.Command, args []string) {
+			name, _ := cmd.Flags().GetString("name")
+			if !regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`).MatchString(name) {
+				log.Fatalf("%s is not a valid function name\n", name)
+			}
+			script, ok := shellInitScripts[args[0]]
+			if !ok {
+				// zsh understands the bash function
+				script = shellInitScripts["bash"]
+			}
+			t := template.Must(template.New("shell-init").Parse(script))
+			data := struct{ Shell, Name string }{args[0], name}
+			if err := t.Execute(os.Stdout, data); err != nil {
+				log.Fatalln(err)
+			}
+		},
+	}
+	shellInitCmd.Flags().String("name", "gcd", "name of the jump function")
+
+	/*showCmd is a subcommand to set repositories as VISIBLE ones
+	 */
+	var showCmd = &cobra.Command{
+		Use:     "show",
+		Example: "goyave show myRepositoryName\ngoyave show 'myRepository*'",
+		Short:   "Set repositories as VISIBLE ones",
+		Long:    "Repository names can be shell patterns.",
+		Run: func(cmd *cobra.Command, args []string) {
+			setRepositoriesTarget(args, consts.VisibleFlag)
+		},
+	}
+
+	/*stashesCmd is a subcommand to list the stashes of visible git repositories
+	 */
+	var stashesCmd = &cobra.Command{
+		Use:     "stashes",
+		Example: "goyave stashes\ngoyave stashes --stat myRepositoryName",
+		Short:   "List the stashes of each local visible git repository",
+		Long:    "List the stashes of visible git repositories, with their message, their age and the branch they have been created on.\nUse --stat to get the statistics of the stashed changes.",
+		Run: func(cmd *cobra.Command, args []string) {
+			withStat, _ := cmd.Flags().GetBool("stat")
+			jobs, _ := cmd.Flags().GetInt("jobs")
+			repositories := selectRepositories(cmd, args)
+			results := make([][]gitManip.StashInfo, len(repositories))
+			utils.ParallelRun(len(repositories), jobs, func(i int) {
+				stashes, err := gitManip.New(repositories[i].Path).Stashes(withStat)
+				if err != nil {
+					traces.ErrorTracer.Printf("[%s] %s\n", repositories[i].Name, err)
+				}
+				results[i] = stashes
+			})
+			for i, repository := range repositories {
+				if len(results[i]) == 0 {
+					continue
+				}
+				fmt.Printf("%s %s\t[%d stash(es)]\n", color.YellowString("⚑"), repository.Name, len(results[i]))
+				for _, stash := range results[i] {
+					branch := stash.Branch
+					if branch == "" {
+						branch = "unknown branch"
+					}
+					fmt.Printf("\tstash@{%d} (%s, on %s): %s\n", stash.Index, utils.RelativeTime(stash.Date), color.MagentaString(branch), stash.Message)
+					if stash.Stat != nil {
+						fmt.Printf("\t\t%d file(s) changed, %s, %s\n", stash.Stat.FilesChanged, color.GreenString("%d insertion(s)(+)", stash.Stat.Insertions), color.RedString("%d deletion(s)(-)", stash.Stat.Deletions))
+					}
+				}
+			}
+		},
+	}
+	stashesCmd.Flags().Bool("stat", false, "show the statistics of the stashed changes")
+	stashesCmd.Flags().IntP("jobs", "j", 4, "number of repositories to read at the same time")
 
 	/*stateCmd is a subcommand to list the state of each local git repository.
 	 */
 	var stateCmd = &cobra.Command{
-		Use:     "state",
-		Example: "goyave state\ngoyave state myRepositoryName\ngoyave state myRepositoryName1 myRepositoryName2",
-		Short:   "Get the state of each local visible git repository",
-		Long:    "Check only visible git repositories.\nIf some repository names have been setted, goyave will only check those repositories, otherwise it checks all visible repositories of your system.",
+		Use:         "state",
+		Annotations: map[string]string{parsedOutputAnnotation: "true"},
+		Example:     "goyave state\ngoyave state myRepositoryName\ngoyave state myRepositoryName1 myRepositoryName2\ngoyave state --format ndjson\ngoyave state --template '{{.Name}} {{.Ahead}}'\ngoyave state --template '{{pad 20 .Name}} {{.Branch | color \"cyan\"}} {{relativeTime .LastCommit}}'",
+		Short:       "Get the state of each local visible git repository",
+		Long:        "Check only visible git repositories.\nIf some repository names have been setted, goyave will only check those repositories, otherwise it checks all visible repositories of your system.\nThe repositories with a merge, a rebase, a bisect or a cherry-pick in progress, or with conflicted files, need attention: goyave exits with the status 2 if one of them does (or 1 if a repository can't be read).\nAvailable formats are text, json (one document), ndjson (one document per repository) porcelain (stable lines, for scripts) and template (using the Go text/template syntax, given with --template or --template-file, on each repository document): their schema is described in the README, and is versioned.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var paths []string
-			// Append repositories to check
-			if len(args) == 0 {
-				for _, p := range configurationFileStructure.VisibleRepositories {
-					paths = append(paths, p)
+			jobs, _ := cmd.Flags().GetInt("jobs")
+			format, _ := cmd.Flags().GetString("format")
+			switch format {
+			case "text":
+			case "json", "ndjson", "porcelain":
+				// The machine-readable formats never contain color codes
+				color.NoColor = true
+			case "template":
+			default:
+				log.Fatalf("unknown format %s\n", format)
+			}
+			t, err := readTemplate(cmd)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			if format, err = templateFormat(cmd, t); err != nil {
+				log.Fatalln(err)
+			}
+			if format == "template" && t == nil {
+				log.Fatalln("the template format needs the --template or --template-file flag")
+			}
+			repositories := selectRepositories(cmd, args)
+			statuses := make([]*gitManip.RepoStatus, len(repositories))
+			utils.ParallelRun(len(repositories), jobs, func(i int) {
+				statuses[i] = gitManip.New(repositories[i].Path).Inspect()
+			})
+			var attention []string
+			reports := []gitManip.StatusReport{}
+			for i, status := range statuses {
+				if status.NeedsAttention() {
+					attention = append(attention, repositories[i].Name)
 				}
-			} else {
-				for _, repository := range args {
-					repoPath, ok := configurationFileStructure.VisibleRepositories[repository]
-					if ok {
-						paths = append(paths, repoPath)
-					} else {
-						traces.WarningTracer.Printf("%s cannot be found in your visible repositories\n", repository)
-					}
+				if status.Err != nil {
+					exitCode = 1
 				}
+				reports = append(reports, status.Report(repositories[i].Name))
 			}
-			var wg sync.WaitGroup
-			for _, repository := range paths {
-				wg.Add(1)
-				go func(repoPath string) {
-					defer wg.Done()
-					cGitObj := gitManip.New(repoPath)
-					cGitObj.Status()
-				}(repository)
+			// Errors are more important than operations in progress
+			if len(attention) > 0 && exitCode == 0 {
+				exitCode = 2
+			}
+			switch format {
+			case "text":
+				for _, status := range statuses {
+					fmt.Print(status.Text())
+				}
+				if len(attention) > 0 {
+					fmt.Printf("%s %d of %d repositories need attention: %s\n", color.YellowString("⚠"), len(attention), len(statuses), strings.Join(attention, ", "))
+				}
+			case "json":
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				document := struct {
+					SchemaVersion int                     `json:"schema_version"`
+					Repositories  []gitManip.StatusReport `json:"repositories"`
+				}{gitManip.StatusSchemaVersion, reports}
+				if err := encoder.Encode(document); err != nil {
+					log.Fatalln(err)
+				}
+			case "ndjson":
+				encoder := json.NewEncoder(os.Stdout)
+				for _, report := range reports {
+					report.SchemaVersion = gitManip.StatusSchemaVersion
+					if err := encoder.Encode(report); err != nil {
+						log.Fatalln(err)
+					}
+				}
+			case "porcelain":
+				fmt.Printf("# goyave state porcelain v%d\n", gitManip.StatusSchemaVersion)
+				for _, report := range reports {
+					fmt.Print(report.Porcelain())
+				}
+			case "template":
+				for _, report := range reports {
+					if err := executeTemplate(t, report); err != nil {
+						log.Fatalln(err)
+					}
+				}
 			}
-			wg.Wait()
 		},
 	}
+	stateCmd.Flags().IntP("jobs", "j", 8, "number of repositories to check at the same time")
+	stateCmd.Flags().StringP("format", "f", "text", "output format: text, json, ndjson, porcelain or template")
+	stateCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"text", "json", "ndjson", "porcelain", "template"}, cobra.ShellCompDirectiveNoFileComp))
 
-	rootCmd.AddCommand(addCmd, crawlCmd, loadCmd, pathCmd, stateCmd)
+	rootCmd.AddCommand(addCmd, aliasCmd, branchesCmd, completionCmd, crawlCmd, doctorCmd, execCmd, fetchCmd, grepCmd, hideCmd, initCmd, listCmd, loadCmd, logCmd, pathCmd, pruneCmd, pullCmd, pushCmd, recentCmd, removeCmd, renameCmd, shellInitCmd, showCmd, stashesCmd, stateCmd, tagCmd)
+	addTagFlags(branchesCmd, execCmd, fetchCmd, grepCmd, listCmd, logCmd, pullCmd, pushCmd, stashesCmd, stateCmd)
+	addTemplateFlags(listCmd, stateCmd)
+
+	// Complete repository names, groups, hosts and tags
+	for _, cmd := range []*cobra.Command{branchesCmd, fetchCmd, logCmd, pathCmd, pullCmd, pushCmd, stashesCmd, stateCmd} {
+		cmd.ValidArgsFunction = completeVisibleRepositories(0)
+	}
+	grepCmd.ValidArgsFunction = completeVisibleRepositories(1)
+	removeCmd.ValidArgsFunction = completeRepositories("", 0, -1)
+	hideCmd.ValidArgsFunction = completeRepositories(consts.VisibleFlag, 0, -1)
+	showCmd.ValidArgsFunction = completeRepositories(consts.HiddenFlag, 0, -1)
+	for _, cmd := range []*cobra.Command{aliasAddCmd, aliasRmCmd, renameCmd} {
+		cmd.ValidArgsFunction = completeRepositories("", 0, 1)
+	}
+	for _, cmd := range []*cobra.Command{tagAddCmd, tagRmCmd} {
+		cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return completeRepositories("", 0, 1)(cmd, args, toComplete)
+			}
+			return completeTags(cmd, args, toComplete)
+		}
+	}
+	for _, cmd := range []*cobra.Command{branchesCmd, execCmd, fetchCmd, grepCmd, listCmd, logCmd, pullCmd, pushCmd, stashesCmd, stateCmd} {
+		cmd.RegisterFlagCompletionFunc("tag", completeTags)
+		cmd.RegisterFlagCompletionFunc("not-tag", completeTags)
+	}
+	execCmd.RegisterFlagCompletionFunc("group", completeGroups)
+	listCmd.RegisterFlagCompletionFunc("group", completeGroups)
+	listCmd.RegisterFlagCompletionFunc("host", completeHosts)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
+	os.Exit(exitCode)
 }
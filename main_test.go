@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+	recent := time.Now().Add(-3 * time.Hour)
+	data := struct {
+		Name   string
+		Ahead  int
+		Never  *time.Time
+		Zero   time.Time
+		Recent *time.Time
+		Tags   []string
+	}{"goyave", 2, nil, time.Time{}, &recent, []string{"go", "cli"}}
+	tests := []struct {
+		text     string
+		expected string
+		fails    bool
+	}{
+		{`{{color "red" .Name}}`, "\x1b[31mgoyave\x1b[0m", false},
+		{`{{color "bold, red" .Name}}`, "\x1b[1;31mgoyave\x1b[0m", false},
+		{`{{color "pink" .Name}}`, "", true},
+		{`[{{pad 8 .Name}}]`, "[goyave  ]", false},
+		{`[{{padLeft 8 .Name}}]`, "[  goyave]", false},
+		{`[{{pad 2 .Name}}]`, "[goyave]", false},
+		{`[{{padLeft 4 .Ahead}}]`, "[   2]", false},
+		{`{{relativeTime .Never}}`, "never", false},
+		{`{{relativeTime .Zero}}`, "never", false},
+		{`{{relativeTime .Recent}}`, "3 hours ago", false},
+		{`{{relativeTime .Name}}`, "", true},
+		{`{{join ", " .Tags}}`, "go, cli", false},
+		{`{{join ", " .Never}}`, "", true},
+	}
+	for _, test := range tests {
+		var buffer bytes.Buffer
+		err := template.Must(template.New("test").Funcs(templateFuncs).Parse(test.text)).Execute(&buffer, data)
+		if test.fails {
+			if err == nil {
+				t.Errorf("The template %s should fail, got %q.", test.text, buffer.String())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("The template %s failed: %s", test.text, err)
+		} else if buffer.String() != test.expected {
+			t.Errorf("The output of the template %s is not correct, got %q instead of %q.", test.text, buffer.String(), test.expected)
+		}
+	}
+}
+
+func TestTemplateFlags(t *testing.T) {
+	directory, err := ioutil.TempDir("", "goyave-template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	templateFile := filepath.Join(directory, "template")
+	ioutil.WriteFile(templateFile, []byte("{{.Name}}\n"), 0644)
+	tests := []struct {
+		args     []string
+		format   string
+		template bool
+		fails    bool
+	}{
+		{nil, "text", false, false},
+		{[]string{"--format", "json"}, "json", false, false},
+		{[]string{"--template", "{{.Name}}"}, "template", true, false},
+		{[]string{"--template-file", templateFile}, "template", true, false},
+		{[]string{"--template", "{{.Name}}", "--format", "template"}, "template", true, false},
+		{[]string{"--template", "{{.Name}}", "--format", "json"}, "", true, true},
+		{[]string{"--template-file", templateFile, "--format", "text"}, "", true, true},
+		{[]string{"--template", "{{.Name}}", "--template-file", templateFile}, "", false, true},
+		{[]string{"--template", "{{.Name"}, "", false, true},
+	}
+	for _, test := range tests {
+		cmd := &cobra.Command{Use: "state"}
+		cmd.Flags().String("format", "text", "output format")
+		addTemplateFlags(cmd)
+		if err := cmd.Flags().Parse(test.args); err != nil {
+			t.Fatal(err)
+		}
+		tmpl, err := readTemplate(cmd)
+		format := ""
+		if err == nil {
+			format, err = templateFormat(cmd, tmpl)
+		}
+		if test.fails {
+			if err == nil {
+				t.Errorf("The flags %v should be rejected, got the format %s.", test.args, format)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("The flags %v should be accepted, got the error %s.", test.args, err)
+		} else if format != test.format || (tmpl != nil) != test.template {
+			t.Errorf("The format of the flags %v is not correct, got %s instead of %s.", test.args, format, test.format)
+		}
+	}
+}
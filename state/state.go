@@ -0,0 +1,145 @@
+/*Package state implements the local state of goyave: how often and how recently each repository has been used.
+ *The state is specific to a machine, so it is not stored in the (shared) configuration file.
+ */
+package state
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*Usage contains informations about the use of a repository
+ *
+ *The structure is:
+ *	Count:
+ *		The number of times the repository has been used.
+ *	LastAccess:
+ *		The date of the last use of the repository.
+ */
+type Usage struct {
+	Count      int       `json:"count"`
+	LastAccess time.Time `json:"last_access"`
+}
+
+/*Score returns the frecency of the repository: the number of uses, weighted by the time since the last one
+ */
+func (u Usage) Score(now time.Time) float64 {
+	elapsed := now.Sub(u.LastAccess)
+	switch {
+	case elapsed < time.Hour:
+		return float64(u.Count) * 4
+	case elapsed < 24*time.Hour:
+		return float64(u.Count) * 2
+	case elapsed < 7*24*time.Hour:
+		return float64(u.Count) / 2
+	}
+	return float64(u.Count) / 4
+}
+
+/*State is the local state of goyave
+ *
+ *Properties:
+ *	Repositories:
+ *		The use of each repository, by path (the paths are local, and do not change if a repository is renamed)
+ *	locker:
+ *		Mutex to perform concurrent RW on map data structures
+ */
+type State struct {
+	Repositories map[string]Usage `json:"repositories"`
+	locker       sync.RWMutex
+}
+
+/*New is a constructor for an empty State
+ */
+func New() *State {
+	return &State{Repositories: make(map[string]Usage)}
+}
+
+/*Load reads the state stored in the given file.
+ *If the file does not exist, it returns an empty state.
+ */
+func Load(path string) (*State, error) {
+	s := New()
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, s); err != nil {
+		return nil, err
+	}
+	if s.Repositories == nil {
+		s.Repositories = make(map[string]Usage)
+	}
+	return s, nil
+}
+
+/*Save writes the state in the given file.
+ *The file is replaced at once, so concurrent goyave processes never read a partial state.
+ */
+func (s *State) Save(path string) error {
+	s.locker.RLock()
+	content, err := json.MarshalIndent(s, "", "  ")
+	s.locker.RUnlock()
+	if err != nil {
+		return err
+	}
+	temporaryFile, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporaryFile.Name())
+	if _, err := temporaryFile.Write(content); err != nil {
+		temporaryFile.Close()
+		return err
+	}
+	if err := temporaryFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temporaryFile.Name(), path)
+}
+
+/*Record records a use of the repository located at the given path
+ */
+func (s *State) Record(path string, now time.Time) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	usage := s.Repositories[path]
+	usage.Count++
+	usage.LastAccess = now
+	s.Repositories[path] = usage
+}
+
+/*Score returns the frecency of the repository located at the given path (0 if the repository has never been used)
+ */
+func (s *State) Score(path string, now time.Time) float64 {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+	return s.Repositories[path].Score(now)
+}
+
+/*Recent returns the paths of the used repositories, from the highest to the lowest frecency
+ */
+func (s *State) Recent(now time.Time) []string {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+	var paths []string
+	for path := range s.Repositories {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		scoreI, scoreJ := s.Repositories[paths[i]].Score(now), s.Repositories[paths[j]].Score(now)
+		if scoreI != scoreJ {
+			return scoreI > scoreJ
+		}
+		return s.Repositories[paths[i]].LastAccess.After(s.Repositories[paths[j]].LastAccess)
+	})
+	return paths
+}
@@ -0,0 +1,62 @@
+package state
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadMissingFile(t *testing.T) {
+	s, err := Load(filepath.Join(os.TempDir(), "goyave-missing-state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Repositories) != 0 {
+		t.Errorf("The state is not correct, got %v instead of an empty state.", s.Repositories)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	directory, err := ioutil.TempDir("", "goyave-state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	statePath := filepath.Join(directory, "state")
+	now := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	s, _ := Load(statePath)
+	s.Record("/src/goyave", now)
+	s.Record("/src/goyave", now)
+	if err := s.Save(statePath); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage := loaded.Repositories["/src/goyave"]
+	if usage.Count != 2 || !usage.LastAccess.Equal(now) {
+		t.Errorf("The usage is not correct, got %+v instead of 2 uses at %s.", usage, now)
+	}
+}
+
+func TestRecent(t *testing.T) {
+	now := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := &State{Repositories: map[string]Usage{
+		// Used often, but a long time ago
+		"/src/old": {Count: 10, LastAccess: now.Add(-30 * 24 * time.Hour)},
+		// Used a few times, recently
+		"/src/recent": {Count: 2, LastAccess: now.Add(-10 * time.Minute)},
+		"/src/today":  {Count: 2, LastAccess: now.Add(-5 * time.Hour)},
+	}}
+	expected := []string{"/src/recent", "/src/today", "/src/old"}
+	if recent := s.Recent(now); !reflect.DeepEqual(recent, expected) {
+		t.Errorf("The recent repositories are not correct, got %v instead of %v.", recent, expected)
+	}
+	if score := s.Score("/src/unknown", now); score != 0 {
+		t.Errorf("The score of an unknown repository is not correct, got %f instead of 0.", score)
+	}
+}
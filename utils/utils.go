@@ -1,9 +1,15 @@
 package utils
 
 import (
+	"bufio"
+	"fmt"
 	"os"
 	"os/user"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/k0pernicus/goyave/consts"
 )
@@ -34,6 +40,17 @@ func GetUserHomeDir() string {
 	return usr.HomeDir
 }
 
+/*GetUserName returns the name of the current user.
+ *If there is an error, it returns a default string.
+ */
+func GetUserName() string {
+	usr, err := user.Current()
+	if err != nil {
+		return consts.DefaultUserName
+	}
+	return usr.Username
+}
+
 /*GetHostname returns the hostname name of the current computer.
  *If there is an error, it returns a default string.
  */
@@ -56,3 +73,118 @@ func SliceIndex(limit int, predicate func(i int) bool) int {
 	}
 	return -1
 }
+
+/*stdinReader is the reader used to get the user answers, shared to not lose buffered input between questions
+ */
+var stdinReader = bufio.NewReader(os.Stdin)
+
+/*AskConfirmation asks the user to confirm an action, from the standard input.
+ *This function returns true only if the user answered yes.
+ */
+func AskConfirmation(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, err := stdinReader.ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+/*AskString asks the user a question, from the standard input.
+ *If the user gives an empty answer, this function returns the default value.
+ */
+func AskString(question, defaultValue string) string {
+	fmt.Printf("%s [%s]: ", question, defaultValue)
+	answer, _ := stdinReader.ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return defaultValue
+	}
+	return answer
+}
+
+/*AskChoice asks the user to choose one of the given choices, by number.
+ *The question and the choices are written to the standard error, to keep the standard output for the result.
+ *This function returns the index of the chosen item, or -1 if the answer is not a valid choice.
+ */
+func AskChoice(question string, choices []string) int {
+	for i, choice := range choices {
+		fmt.Fprintf(os.Stderr, "%d) %s\n", i+1, choice)
+	}
+	fmt.Fprintf(os.Stderr, "%s [1-%d]: ", question, len(choices))
+	answer, _ := stdinReader.ReadString('\n')
+	choice, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || choice < 1 || choice > len(choices) {
+		return -1
+	}
+	return choice - 1
+}
+
+/*IsTerminal returns if the given file is a terminal
+ */
+func IsTerminal(f *os.File) bool {
+	fileInfo, err := f.Stat()
+	return err == nil && fileInfo.Mode()&os.ModeCharDevice != 0
+}
+
+/*ParallelRun calls the function f for each index between 0 and n (excluded), using at most jobs goroutines
+ *at the same time.
+ *This function returns once each call is finished.
+ */
+func ParallelRun(n, jobs int, f func(i int)) {
+	if jobs < 1 {
+		jobs = 1
+	}
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, jobs)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			f(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+/*ParseDuration parses a duration, like time.ParseDuration does, but also accepts days (like "7d") and weeks
+ *(like "2w").
+ */
+func ParseDuration(duration string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if strings.HasSuffix(duration, suffix) {
+			if n, err := strconv.Atoi(strings.TrimSuffix(duration, suffix)); err == nil {
+				return time.Duration(n) * unit, nil
+			}
+		}
+	}
+	return time.ParseDuration(duration)
+}
+
+/*RelativeTime returns a readable description of the time elapsed since the given date, like "3 days ago".
+ */
+func RelativeTime(date time.Time) string {
+	elapsed := time.Since(date)
+	units := []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"year", 365 * 24 * time.Hour},
+		{"month", 30 * 24 * time.Hour},
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+	}
+	for _, unit := range units {
+		if n := int(elapsed / unit.duration); n > 0 {
+			if n == 1 {
+				return fmt.Sprintf("1 %s ago", unit.name)
+			}
+			return fmt.Sprintf("%d %ss ago", n, unit.name)
+		}
+	}
+	return "just now"
+}
@@ -14,6 +14,11 @@ import (
 func RetrieveGitRepositories(rootpath string) ([]string, error) {
 	var gitPaths []string
 	err := filepath.Walk(rootpath, func(pathdir string, fileInfo os.FileInfo, err error) error {
+		// Skip the files and directories that can't be read
+		if err != nil {
+			traces.DebugTracer.Printf("Can't access to %s: %s\n", pathdir, err)
+			return nil
+		}
 		if fileInfo.IsDir() && filepath.Base(pathdir) == consts.GitFileName {
 			fileDir := filepath.Dir(pathdir)
 			traces.DebugTracer.Printf("Just found in hard drive %s\n", fileDir)